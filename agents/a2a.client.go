@@ -51,6 +51,9 @@ func (agent *Agent) SendToAgent(agentBaseURL string, taskRequest TaskRequest) (T
 	if err := json.NewDecoder(resp.Body).Decode(&taskResponse); err != nil {
 		return TaskResponse{}, err
 	}
+	if taskResponse.Error != nil {
+		return taskResponse, taskResponse.Error
+	}
 
 	return taskResponse, nil
 }
@@ -0,0 +1,272 @@
+package agents
+
+/* NOTE:
+	This A2A protocol implementation is a subset of the A2A specification.
+	IMPORTANT:
+	This is a work in progress and may not cover all aspects of the A2A protocol.
+*/
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A2AClientConfig configures an A2AClient.
+type A2AClientConfig struct {
+	HTTPClient *http.Client  // Default: http.DefaultClient
+	Timeout    time.Duration // Timeout of each request, except the streams (default: 60 seconds)
+
+	// Retries of the requests failing with a network error or a 429/5xx status.
+	// Only the idempotent requests are retried (agent card, tasks/get), unless RetryMessages is set.
+	MaxRetries   int           // Default: 2, negative: no retry
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each retry (default: 500ms)
+	// RetryMessages retries the other requests too (message/send, message/stream, tasks/cancel).
+	// NOTE: a retried message/send can create the same task twice on the remote agent.
+	RetryMessages bool
+
+	// Authentication
+	BearerToken  string            // Authorization: Bearer <token>
+	APIKey       string            // Sent with the APIKeyHeader header
+	APIKeyHeader string            // Default: "X-API-Key"
+	Headers      map[string]string // Additional headers
+
+	CardTTL time.Duration // Duration of the agent cards in the cache (default: 5 minutes)
+}
+
+// A2AClient talks to remote A2A agents, with a cache of their agent cards, retries and authentication.
+type A2AClient struct {
+	config A2AClientConfig
+
+	mutex sync.Mutex
+	cards map[string]cachedAgentCard
+}
+
+type cachedAgentCard struct {
+	card      AgentCard
+	expiresAt time.Time
+}
+
+// NewA2AClient creates an A2A client with the given configuration.
+func NewA2AClient(config A2AClientConfig) *A2AClient {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 60 * time.Second
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 2
+	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = 500 * time.Millisecond
+	}
+	if config.APIKeyHeader == "" {
+		config.APIKeyHeader = "X-API-Key"
+	}
+	if config.CardTTL == 0 {
+		config.CardTTL = 5 * time.Minute
+	}
+	return &A2AClient{
+		config: config,
+		cards:  make(map[string]cachedAgentCard),
+	}
+}
+
+// GetAgentCard returns the agent card of the agent (/.well-known/agent.json), from the cache if it is not expired.
+func (client *A2AClient) GetAgentCard(ctx context.Context, agentBaseURL string) (AgentCard, error) {
+	agentBaseURL = strings.TrimSuffix(agentBaseURL, "/")
+
+	client.mutex.Lock()
+	cached, ok := client.cards[agentBaseURL]
+	client.mutex.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.card, nil
+	}
+	return client.RefreshAgentCard(ctx, agentBaseURL)
+}
+
+// RefreshAgentCard fetches the agent card of the agent and updates the cache.
+func (client *A2AClient) RefreshAgentCard(ctx context.Context, agentBaseURL string) (AgentCard, error) {
+	agentBaseURL = strings.TrimSuffix(agentBaseURL, "/")
+
+	var agentCard AgentCard
+	body, err := client.do(ctx, http.MethodGet, agentBaseURL+"/.well-known/agent.json", nil, true)
+	if err != nil {
+		return AgentCard{}, err
+	}
+	if err := json.Unmarshal(body, &agentCard); err != nil {
+		return AgentCard{}, err
+	}
+
+	client.mutex.Lock()
+	client.cards[agentBaseURL] = cachedAgentCard{card: agentCard, expiresAt: time.Now().Add(client.config.CardTTL)}
+	client.mutex.Unlock()
+	return agentCard, nil
+}
+
+// SendMessage sends the task request to the agent (message/send by default).
+// A JSON-RPC error is returned as a *JSONRPCError.
+func (client *A2AClient) SendMessage(ctx context.Context, agentBaseURL string, taskRequest TaskRequest) (TaskResponse, error) {
+	if taskRequest.Method == "" {
+		taskRequest.Method = "message/send"
+	}
+	return client.call(ctx, agentBaseURL, taskRequest)
+}
+
+// GetTask gets the task with the given ID from the agent (tasks/get).
+func (client *A2AClient) GetTask(ctx context.Context, agentBaseURL string, taskID string) (TaskResponse, error) {
+	return client.call(ctx, agentBaseURL, TaskRequest{Method: "tasks/get", Params: AgentMessageParams{ID: taskID}})
+}
+
+// CancelTask cancels the task with the given ID on the agent (tasks/cancel).
+func (client *A2AClient) CancelTask(ctx context.Context, agentBaseURL string, taskID string) (TaskResponse, error) {
+	return client.call(ctx, agentBaseURL, TaskRequest{Method: "tasks/cancel", Params: AgentMessageParams{ID: taskID}})
+}
+
+// StreamMessage sends the task request to the agent with message/stream and calls callback for each event
+// (see Agent.StreamToAgent). Only the connection is retried (with RetryMessages), not the stream.
+func (client *A2AClient) StreamMessage(ctx context.Context, agentBaseURL string, taskRequest TaskRequest, callback func(event TaskStreamEvent) error) error {
+	taskRequest.Method = "message/stream"
+	jsonTaskRequest, err := json.Marshal(withJSONRPCDefaults(taskRequest))
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.doWithRetries(ctx, client.config.RetryMessages, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(agentBaseURL, "/")+"/", bytes.NewReader(jsonTaskRequest))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "text/event-stream")
+		return request, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readTaskStream(resp.Body, callback)
+}
+
+// call sends the JSON-RPC request. Only tasks/get is retried, unless RetryMessages is set.
+func (client *A2AClient) call(ctx context.Context, agentBaseURL string, taskRequest TaskRequest) (TaskResponse, error) {
+	jsonTaskRequest, err := json.Marshal(withJSONRPCDefaults(taskRequest))
+	if err != nil {
+		return TaskResponse{}, err
+	}
+	retry := taskRequest.Method == "tasks/get" || client.config.RetryMessages
+	body, err := client.do(ctx, http.MethodPost, strings.TrimSuffix(agentBaseURL, "/")+"/", jsonTaskRequest, retry)
+	if err != nil {
+		return TaskResponse{}, err
+	}
+
+	var taskResponse TaskResponse
+	if err := json.Unmarshal(body, &taskResponse); err != nil {
+		return TaskResponse{}, err
+	}
+	if taskResponse.Error != nil {
+		return taskResponse, taskResponse.Error
+	}
+	return taskResponse, nil
+}
+
+// do sends a request with the timeout of the client (and its retries if retry is set) and returns the body of the response.
+func (client *A2AClient) do(ctx context.Context, method string, url string, body []byte, retry bool) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.config.Timeout)
+	defer cancel()
+
+	resp, err := client.doWithRetries(ctx, retry, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		return request, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// doWithRetries sends the request built by newRequest, with the authentication headers,
+// and, if retry is set, retries it with an exponential backoff on network errors and 429/5xx statuses.
+// It returns an error for the other non 200 statuses.
+func (client *A2AClient) doWithRetries(ctx context.Context, retry bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	backoff := client.config.RetryBackoff
+	maxRetries := max(client.config.MaxRetries, 0)
+	if !retry {
+		maxRetries = 0
+	}
+	var lastErr error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, errors.Join(lastErr, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		request, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		client.setHeaders(request)
+
+		resp, err := client.config.HTTPClient.Do(request)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		resp.Body.Close()
+		lastErr = fmt.Errorf("failed to call %s: %s", request.URL, resp.Status)
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return nil, lastErr
+		}
+	}
+	return nil, lastErr
+}
+
+func (client *A2AClient) setHeaders(request *http.Request) {
+	for key, value := range client.config.Headers {
+		request.Header.Set(key, value)
+	}
+	if client.config.BearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+client.config.BearerToken)
+	}
+	if client.config.APIKey != "" {
+		request.Header.Set(client.config.APIKeyHeader, client.config.APIKey)
+	}
+}
+
+func withJSONRPCDefaults(taskRequest TaskRequest) TaskRequest {
+	if taskRequest.JSONRpcVersion == "" {
+		taskRequest.JSONRpcVersion = "2.0"
+	}
+	if taskRequest.ID == "" {
+		taskRequest.ID = uuid.NewString()
+	}
+	return taskRequest
+}
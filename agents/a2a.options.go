@@ -7,9 +7,15 @@ package agents
 */
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type A2AServerConfig struct {
@@ -22,12 +28,13 @@ func WithA2AServer(a2aServerConfig A2AServerConfig) AgentOption {
 	return func(agent *Agent) {
 		agent.a2aServerConfig = a2aServerConfig
 		agent.a2aServer = http.NewServeMux()
+		agent.ensureA2ATasks()
 
 		// Register handlers
 		agent.a2aServer.HandleFunc("/.well-known/agent.json", agent.getAgentCard)
 
-		// Use the synchronous handler instead
-		agent.a2aServer.HandleFunc("/", agent.handleTaskSync)
+		// JSON-RPC methods
+		agent.a2aServer.HandleFunc("/", agent.handleTaskRequest)
 
 		//agent.a2aServer.HandleFunc(a2aServerConfig.Endpoint, agent.handleA2ATaskRequest)
 		//agent.a2aServer.HandleFunc(a2aServerConfig.StreamEndPoint, agent.handleA2ATaskStream)
@@ -45,42 +52,376 @@ func (agent *Agent) getAgentCard(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(agent.agentCard)
 }
 
-// Alternative synchronous implementation that should work better
-func (agent *Agent) handleTaskSync(w http.ResponseWriter, r *http.Request) {
+// handleTaskRequest handles the JSON-RPC requests of the A2A protocol:
+//   - message/send: create a task (or continue an input-required task) and run the agent callback
+//   - message/stream: same as message/send, with the updates of the task sent as Server-Sent Events
+//   - tasks/get: get a task
+//   - tasks/cancel: cancel a task
+//
+// The errors are returned as JSON-RPC error objects.
+func (agent *Agent) handleTaskRequest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var taskRequest TaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&taskRequest); err != nil {
-		http.Error(w, `{"error": "invalid request format"}`, http.StatusBadRequest)
+	var taskResponse TaskResponse
+	if err := json.NewDecoder(r.Body).Decode(&taskRequest); requestBodyErrorStatus(err) == http.StatusRequestEntityTooLarge {
+		http.Error(w, "Error: "+err.Error(), http.StatusRequestEntityTooLarge)
 		return
+	} else if err != nil {
+		taskResponse = newJSONRPCErrorResponse("", JSONRPCParseError, "invalid request format: "+err.Error())
+	} else {
+		switch taskRequest.Method {
+		case "message/send":
+			taskResponse = agent.sendMessage(r.Context(), taskRequest)
+		case "message/stream":
+			agent.streamMessage(w, r, taskRequest)
+			return
+		case "tasks/get":
+			taskResponse = agent.getTask(r.Context(), taskRequest)
+		case "tasks/cancel":
+			taskResponse = agent.cancelTask(r.Context(), taskRequest)
+		default:
+			taskResponse = newJSONRPCErrorResponse(taskRequest.ID, JSONRPCMethodNotFound, "unknown method: "+taskRequest.Method)
+		}
 	}
 
-	switch taskRequest.Method {
-	case "message/send":
-		if len(taskRequest.Params.Message.Parts) > 0 {
-			// Process the task synchronously without mutex in the HTTP handler
-			// The mutex should only be in the AgentCallback if needed
-			responseTask, err := agent.agentCallback(taskRequest)
-			if err != nil {
-				log.Printf("Agent callback failed for task %s: %v", taskRequest.ID, err)
-				http.Error(w, `{"error": "agent callback failed"}`, http.StatusInternalServerError)
-				return
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(taskResponse)
+}
+
+// sendMessage creates a task for the message, or continues the input-required task of the message (message.taskId).
+// By default, the task runs during the request; with configuration.blocking set to false, the submitted task
+// is returned immediately and runs in the background.
+func (agent *Agent) sendMessage(ctx context.Context, taskRequest TaskRequest) TaskResponse {
+	taskRequest, task, errorResponse := agent.submitTask(ctx, taskRequest)
+	if errorResponse != nil {
+		return *errorResponse
+	}
+
+	configuration := taskRequest.Params.Configuration
+	if configuration != nil && configuration.Blocking != nil && !*configuration.Blocking {
+		// NOTE: the task is not stopped when the client disconnects, only with tasks/cancel (or Close),
+		// and the graceful shutdown of the server waits for it
+		agent.a2aTasks.background.Add(1)
+		go func() {
+			defer agent.a2aTasks.background.Done()
+			agent.runTask(context.WithoutCancel(ctx), taskRequest, nil)
+		}()
+		return TaskResponse{
+			JSONRpcVersion: "2.0",
+			ID:             taskRequest.ID,
+			Result:         truncateHistory(task, configuration.HistoryLength),
+		}
+	}
+	return agent.runTask(ctx, taskRequest, nil)
+}
+
+// submitTask creates the task of the message (submitted state), or continues the input-required task of the message.
+// It returns the request with the task and context IDs set in the message.
+func (agent *Agent) submitTask(ctx context.Context, taskRequest TaskRequest) (TaskRequest, Result, *TaskResponse) {
+	tasks := agent.a2aTasks
+	message := taskRequest.Params.Message
+	if len(message.Parts) == 0 {
+		errorResponse := newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInvalidParams, "the message has no parts")
+		return taskRequest, Result{}, &errorResponse
+	}
+
+	var task Result
+	if message.TaskID != "" {
+		// Continue an input-required task
+		var changed bool
+		var err error
+		task, changed, err = tasks.setState(ctx, message.TaskID, func(task *Result) error {
+			if task.Status.State != TaskStateInputRequired {
+				return fmt.Errorf("the task is not waiting for input (state: %s)", task.Status.State)
 			}
+			message.ContextID = task.ContextID
+			task.Status = TaskStatus{State: TaskStateSubmitted}
+			task.History = append(task.History, message)
+			return nil
+		})
+		if errors.Is(err, ErrTaskNotFound) {
+			errorResponse := newJSONRPCErrorResponse(taskRequest.ID, A2ATaskNotFoundError, "task not found: "+message.TaskID)
+			return taskRequest, task, &errorResponse
+		}
+		if err == nil && !changed {
+			err = fmt.Errorf("the task is not waiting for input (state: %s)", task.Status.State)
+		}
+		if err != nil {
+			errorResponse := newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInvalidParams, err.Error())
+			return taskRequest, task, &errorResponse
+		}
+	} else {
+		message.TaskID = uuid.NewString()
+		if message.ContextID == "" {
+			message.ContextID = uuid.NewString()
+		}
+		task = Result{
+			ID:        message.TaskID,
+			ContextID: message.ContextID,
+			Kind:      "task",
+			Status: TaskStatus{
+				State:     TaskStateSubmitted,
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+			},
+			History:  []AgentMessage{message},
+			Metadata: taskRequest.Params.MetaData,
+		}
+		if err := tasks.store.Save(ctx, task); err != nil {
+			errorResponse := newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInternalError, err.Error())
+			return taskRequest, task, &errorResponse
+		}
+	}
+	taskRequest.Params.Message = message
+	return taskRequest, task, nil
+}
+
+// runTask runs the agent callback for the task of the message and saves the new state of the task.
+// The state of the task is the state returned by the callback (default: completed), or failed if the callback fails
+// (the failed task is returned, with the error as the message of its status).
+// The context of the callback is cancelled by tasks/cancel.
+// If emit is not nil (message/stream), the status updates and the content streamed by the callback
+// (AgentCallbackContext.StreamContent) are emitted as events; the last event is the final status update.
+func (agent *Agent) runTask(parent context.Context, taskRequest TaskRequest, emit func(event TaskStreamEvent)) TaskResponse {
+	tasks := agent.a2aTasks
+	taskID := taskRequest.Params.Message.TaskID
+	storeCtx := context.WithoutCancel(parent)
+
+	ctx, done := tasks.running.startWithID(taskID, parent)
+	defer done()
+
+	streaming := emit != nil
+	if !streaming {
+		emit = func(event TaskStreamEvent) {}
+	}
+	emitStatus := func(task Result, final bool) {
+		emit(TaskStreamEvent{StatusUpdate: &TaskStatusUpdateEvent{
+			TaskID:    task.ID,
+			ContextID: task.ContextID,
+			Kind:      "status-update",
+			Status:    task.Status,
+			Final:     final,
+		}})
+	}
+
+	var pendingApproval ToolApprovalRequest
+	var approvalRetries int
+	var hasPendingApproval bool
+	task, changed, err := tasks.setState(storeCtx, taskID, func(task *Result) error {
+		task.Status = TaskStatus{State: TaskStateWorking}
+		pendingApproval, approvalRetries, hasPendingApproval = takeA2AToolApproval(task)
+		return nil
+	})
+	if err != nil {
+		return newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInternalError, err.Error())
+	}
+	if !changed {
+		// Cancelled before starting
+		emitStatus(task, true)
+		return TaskResponse{JSONRpcVersion: "2.0", ID: taskRequest.ID, Result: task}
+	}
+	emitStatus(task, false)
 
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(responseTask)
+	// The content streamed by the callback is sent as the chunks of an artifact
+	streamedArtifact := Artifact{ArtifactID: uuid.NewString(), Name: "response"}
+	streamed := strings.Builder{}
+	var streamContent func(content string) error
+	if streaming {
+		streamContent = func(content string) error {
+			emit(TaskStreamEvent{ArtifactUpdate: &TaskArtifactUpdateEvent{
+				TaskID:    task.ID,
+				ContextID: task.ContextID,
+				Kind:      "artifact-update",
+				Artifact: Artifact{
+					ArtifactID: streamedArtifact.ArtifactID,
+					Name:       streamedArtifact.Name,
+					Parts:      []Part{NewTextPart(content)},
+				},
+				Append: streamed.Len() > 0,
+			}})
+			streamed.WriteString(content)
+			return ctx.Err()
+		}
+	}
+
+	if agent.agentCallback == nil {
+		err = errors.New("no agent callback")
+	}
+	var approvalAnswer *a2aToolApprovalAnswer
+	if hasPendingApproval {
+		// NOTE: the message answers the tool approval of the previous run (A2AToolApprover)
+		approvalAnswer = &a2aToolApprovalAnswer{
+			request: pendingApproval,
+			answer:  taskRequest.Params.Message.Text(),
+		}
+		ctx = context.WithValue(ctx, a2aToolApprovalKey{}, approvalAnswer)
+	}
+	var taskResponse TaskResponse
+	if err == nil {
+		taskResponse, err = agent.agentCallback(ctx, taskRequest, streamContent)
+	}
+
+	// A tool call is waiting for the approval of the client
+	var approvalRequired *ToolApprovalRequiredError
+	if errors.As(err, &approvalRequired) && ctx.Err() == nil {
+		// NOTE: the answer of the client did not apply (another tool call): the approval is asked again
+		if approvalAnswer != nil && !approvalAnswer.used.Load() {
+			approvalRetries++
 		} else {
-			http.Error(w, `{"error": "invalid request format"}`, http.StatusBadRequest)
-			return
+			approvalRetries = 0
 		}
-	default:
-		http.Error(w, `{"error": "unknown method"}`, http.StatusBadRequest)
+		if approvalRetries > A2AMaxToolApprovalRetries {
+			err = fmt.Errorf("the approval of the tool %s was asked %d times in a row: %w", approvalRequired.Request.ToolName, approvalRetries+1, err)
+		} else {
+			task, _, err := tasks.setState(storeCtx, taskID, func(task *Result) error {
+				requireA2AToolApproval(task, approvalRequired.Request, approvalRetries)
+				return nil
+			})
+			if err != nil {
+				return newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInternalError, err.Error())
+			}
+			emitStatus(task, true)
+			return TaskResponse{JSONRpcVersion: "2.0", ID: taskRequest.ID, Result: task}
+		}
+	}
+
+	if err != nil {
+		agent.logger.LogError(agent.Name, "a2a_task", "Agent callback failed", err, map[string]any{
+			"task_id": taskID,
+		})
+		state := TaskStateFailed
+		if ctx.Err() != nil {
+			state = TaskStateCanceled
+		}
+		task, _, storeErr := tasks.setState(storeCtx, taskID, func(task *Result) error {
+			task.Status = TaskStatus{
+				State:   state,
+				Message: &AgentMessage{Role: "agent", Parts: []Part{NewTextPart(err.Error())}, TaskID: taskID, ContextID: task.ContextID},
+			}
+			return nil
+		})
+		if storeErr != nil {
+			return newJSONRPCErrorResponseWithData(taskRequest.ID, JSONRPCInternalError, "agent callback failed: "+err.Error(), map[string]string{"taskId": taskID})
+		}
+		// NOTE: the failed task is returned (and kept for tasks/get), the error is the message of its status
+		emitStatus(task, true)
+		return TaskResponse{JSONRpcVersion: "2.0", ID: taskRequest.ID, Result: task}
+	}
+
+	result := taskResponse.Result
+	task, changed, err = tasks.setState(storeCtx, taskID, func(task *Result) error {
+		task.Status = result.Status
+		if task.Status.State == "" {
+			task.Status.State = TaskStateCompleted
+		}
+		if task.Status.Message == nil && len(result.History) > 0 {
+			task.Status.Message = &result.History[len(result.History)-1]
+		}
+		task.History = append(task.History, result.History...)
+		if streamed.Len() > 0 {
+			streamedArtifact.Parts = []Part{NewTextPart(streamed.String())}
+			task.Artifacts = append(task.Artifacts, streamedArtifact)
+		}
+		task.Artifacts = append(task.Artifacts, result.Artifacts...)
+		if result.Metadata != nil {
+			task.Metadata = result.Metadata
+		}
+		return nil
+	})
+	if err != nil {
+		return newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInternalError, err.Error())
+	}
+	if !changed {
+		// Cancelled while running
+		emitStatus(task, true)
+		return TaskResponse{JSONRpcVersion: "2.0", ID: taskRequest.ID, Result: task}
+	}
+
+	for _, artifact := range result.Artifacts {
+		emit(TaskStreamEvent{ArtifactUpdate: &TaskArtifactUpdateEvent{
+			TaskID:    task.ID,
+			ContextID: task.ContextID,
+			Kind:      "artifact-update",
+			Artifact:  artifact,
+			LastChunk: true,
+		}})
+	}
+	emitStatus(task, true)
+
+	// Return the response of the callback, completed with the task information
+	taskResponse.JSONRpcVersion = "2.0"
+	taskResponse.ID = taskRequest.ID
+	taskResponse.Result.ID = task.ID
+	taskResponse.Result.ContextID = task.ContextID
+	taskResponse.Result.Kind = "task"
+	taskResponse.Result.Status = task.Status
+	return taskResponse
+}
+
+// getTask returns the task of params.id, with the last params.historyLength messages of its history.
+func (agent *Agent) getTask(ctx context.Context, taskRequest TaskRequest) TaskResponse {
+	if taskRequest.Params.ID == "" {
+		return newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInvalidParams, "the task id is missing")
+	}
+	task, err := agent.a2aTasks.store.Get(ctx, taskRequest.Params.ID)
+	if errors.Is(err, ErrTaskNotFound) {
+		return newJSONRPCErrorResponse(taskRequest.ID, A2ATaskNotFoundError, "task not found: "+taskRequest.Params.ID)
+	}
+	if err != nil {
+		return newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInternalError, err.Error())
+	}
+	return TaskResponse{
+		JSONRpcVersion: "2.0",
+		ID:             taskRequest.ID,
+		Result:         truncateHistory(task, taskRequest.Params.HistoryLength),
+	}
+}
+
+// cancelTask cancels the task of params.id and stops its agent callback (through its context).
+// A task in a terminal state cannot be canceled.
+func (agent *Agent) cancelTask(ctx context.Context, taskRequest TaskRequest) TaskResponse {
+	if taskRequest.Params.ID == "" {
+		return newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInvalidParams, "the task id is missing")
+	}
+	task, changed, err := agent.a2aTasks.setState(ctx, taskRequest.Params.ID, func(task *Result) error {
+		task.Status = TaskStatus{State: TaskStateCanceled}
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		return newJSONRPCErrorResponse(taskRequest.ID, A2ATaskNotFoundError, "task not found: "+taskRequest.Params.ID)
+	}
+	if err != nil {
+		return newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInternalError, err.Error())
+	}
+	if !changed {
+		return newJSONRPCErrorResponse(taskRequest.ID, A2ATaskNotCancelableError, "the task cannot be canceled (state: "+task.Status.State+")")
+	}
+	agent.a2aTasks.running.cancel(task.ID)
+
+	return TaskResponse{JSONRpcVersion: "2.0", ID: taskRequest.ID, Result: task}
+}
+
+func newJSONRPCErrorResponse(id string, code int, message string) TaskResponse {
+	return newJSONRPCErrorResponseWithData(id, code, message, nil)
+}
+
+func newJSONRPCErrorResponseWithData(id string, code int, message string, data any) TaskResponse {
+	return TaskResponse{
+		JSONRpcVersion: "2.0",
+		ID:             id,
+		Error:          &JSONRPCError{Code: code, Message: message, Data: data},
 	}
 }
 
+// WithA2ATaskStore sets the store of the A2A tasks (default: a MemoryTaskStore).
+func WithA2ATaskStore(store TaskStore) AgentOption {
+	return func(agent *Agent) {
+		agent.ensureA2ATasks().store = store
+	}
+}
 
 func WithAgentCard(agentCard AgentCard) AgentOption {
 	return func(agent *Agent) {
@@ -90,14 +431,16 @@ func WithAgentCard(agentCard AgentCard) AgentOption {
 
 func WithAgentCallback(callback func(ctx *AgentCallbackContext) (TaskResponse, error)) AgentOption {
 	return func(agent *Agent) {
-		agent.agentCallback = func(taskRequest TaskRequest) (TaskResponse, error) {
+		agent.agentCallback = func(callbackCtx context.Context, taskRequest TaskRequest, streamContent func(content string) error) (TaskResponse, error) {
 			ctx := &AgentCallbackContext{
 				CompletionContext: CompletionContext{
-					Agent:   agent,
-					Context: nil, // You can pass a proper context.Context if available
+					Agent:     agent,
+					Context:   callbackCtx, // Cancelled by tasks/cancel
+					StartTime: time.Now(),
 				},
-				TaskRequest:  &taskRequest,
-				TaskResponse: nil,
+				TaskRequest:   &taskRequest,
+				TaskResponse:  nil,
+				streamContent: streamContent,
 			}
 			return callback(ctx)
 		}
@@ -0,0 +1,236 @@
+package agents
+
+/* NOTE:
+	This A2A protocol implementation is a subset of the A2A specification.
+	IMPORTANT:
+	This is a work in progress and may not cover all aspects of the A2A protocol.
+*/
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Kinds of the parts of a message or an artifact
+const (
+	PartKindText = "text"
+	PartKindFile = "file"
+	PartKindData = "data"
+)
+
+// Part is a part of a message or an artifact: a text part, a file part or a data part.
+// Only the field of the kind of the part is set (Text, File or Data); the kind is inferred when Kind is empty.
+// Use NewTextPart, NewFilePart, NewFilePartFromURI and NewDataPart to build the parts.
+type Part struct {
+	Kind     string         // "text", "file" or "data"
+	Text     string         // Text part
+	File     *FileContent   // File part
+	Data     map[string]any // Data part (arbitrary JSON object)
+	Metadata map[string]any // Optional, for additional metadata
+
+	// Deprecated: use Kind. Type is the kind of the part in the previous versions of the protocol ("text").
+	Type string
+}
+
+// TextPart is the previous name of Part, when the parts could only be text parts.
+type TextPart = Part
+
+// FileContent is the file of a file part: its content (base64 encoded bytes) or its URI.
+type FileContent struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"` // Base64 encoded content
+	URI      string `json:"uri,omitempty"`
+}
+
+// NewTextPart creates a text part.
+func NewTextPart(text string) Part {
+	return Part{Kind: PartKindText, Text: text}
+}
+
+// NewFilePart creates a file part with the content of the file.
+func NewFilePart(name string, mimeType string, content []byte) Part {
+	return Part{Kind: PartKindFile, File: &FileContent{
+		Name:     name,
+		MimeType: mimeType,
+		Bytes:    base64.StdEncoding.EncodeToString(content),
+	}}
+}
+
+// NewFilePartFromURI creates a file part referencing the file with its URI.
+func NewFilePartFromURI(name string, mimeType string, uri string) Part {
+	return Part{Kind: PartKindFile, File: &FileContent{
+		Name:     name,
+		MimeType: mimeType,
+		URI:      uri,
+	}}
+}
+
+// NewDataPart creates a data part from a value serializable as a JSON object (a struct or a map).
+func NewDataPart(value any) (Part, error) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return Part{}, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(jsonData, &data); err != nil {
+		return Part{}, errors.New("the data of a data part must be a JSON object")
+	}
+	return Part{Kind: PartKindData, Data: data}, nil
+}
+
+// PartKind returns the kind of the part ("text", "file" or "data").
+func (part Part) PartKind() string {
+	switch {
+	case part.Kind != "":
+		return part.Kind
+	case part.File != nil:
+		return PartKindFile
+	case part.Data != nil:
+		return PartKindData
+	}
+	return PartKindText
+}
+
+func (part Part) IsText() bool { return part.PartKind() == PartKindText }
+func (part Part) IsFile() bool { return part.PartKind() == PartKindFile }
+func (part Part) IsData() bool { return part.PartKind() == PartKindData }
+
+// DecodeData decodes the data of a data part into target (e.g. a pointer to a struct).
+func (part Part) DecodeData(target any) error {
+	if !part.IsData() {
+		return errors.New("not a data part")
+	}
+	jsonData, err := json.Marshal(part.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, target)
+}
+
+// FileBytes returns the content of a file part. It returns an error if the file is only referenced by its URI.
+func (part Part) FileBytes() ([]byte, error) {
+	if !part.IsFile() || part.File == nil {
+		return nil, errors.New("not a file part")
+	}
+	if part.File.Bytes == "" && part.File.URI != "" {
+		return nil, errors.New("the file is referenced by its URI: " + part.File.URI)
+	}
+	return base64.StdEncoding.DecodeString(part.File.Bytes)
+}
+
+func (part Part) MarshalJSON() ([]byte, error) {
+	type textPart struct {
+		Kind     string         `json:"kind"`
+		Text     string         `json:"text"`
+		Type     string         `json:"type,omitempty"`
+		Metadata map[string]any `json:"metadata,omitempty"`
+	}
+	type filePart struct {
+		Kind     string         `json:"kind"`
+		File     *FileContent   `json:"file"`
+		Metadata map[string]any `json:"metadata,omitempty"`
+	}
+	type dataPart struct {
+		Kind     string         `json:"kind"`
+		Data     map[string]any `json:"data"`
+		Metadata map[string]any `json:"metadata,omitempty"`
+	}
+
+	switch kind := part.PartKind(); kind {
+	case PartKindFile:
+		return json.Marshal(filePart{Kind: kind, File: part.File, Metadata: part.Metadata})
+	case PartKindData:
+		return json.Marshal(dataPart{Kind: kind, Data: part.Data, Metadata: part.Metadata})
+	default:
+		return json.Marshal(textPart{Kind: kind, Text: part.Text, Type: part.Type, Metadata: part.Metadata})
+	}
+}
+
+func (part *Part) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Kind     string         `json:"kind"`
+		Type     string         `json:"type"`
+		Text     string         `json:"text"`
+		File     *FileContent   `json:"file"`
+		Data     map[string]any `json:"data"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*part = Part{
+		Kind:     raw.Kind,
+		Text:     raw.Text,
+		File:     raw.File,
+		Data:     raw.Data,
+		Metadata: raw.Metadata,
+		Type:     raw.Type,
+	}
+	if part.Kind == "" && (raw.Type == PartKindText || raw.Type == PartKindFile || raw.Type == PartKindData) {
+		part.Kind = raw.Type
+	}
+	part.Kind = part.PartKind()
+	return nil
+}
+
+// Text returns the text parts of the message, separated by new lines.
+func (message AgentMessage) Text() string {
+	return textOfParts(message.Parts)
+}
+
+// FileParts returns the file parts of the message.
+func (message AgentMessage) FileParts() []Part {
+	return partsOfKind(message.Parts, PartKindFile)
+}
+
+// DataParts returns the data parts of the message.
+func (message AgentMessage) DataParts() []Part {
+	return partsOfKind(message.Parts, PartKindData)
+}
+
+// Text returns the text parts of the artifact, separated by new lines.
+func (artifact Artifact) Text() string {
+	return textOfParts(artifact.Parts)
+}
+
+// DataParts returns the data parts of the artifact.
+func (artifact Artifact) DataParts() []Part {
+	return partsOfKind(artifact.Parts, PartKindData)
+}
+
+// MessageText returns the text of the message of the task request (AgentMessage.Text).
+func (ctx *AgentCallbackContext) MessageText() string {
+	return ctx.TaskRequest.Params.Message.Text()
+}
+
+// MessageData decodes the first data part of the message of the task request into target.
+func (ctx *AgentCallbackContext) MessageData(target any) error {
+	dataParts := ctx.TaskRequest.Params.Message.DataParts()
+	if len(dataParts) == 0 {
+		return errors.New("the message has no data part")
+	}
+	return dataParts[0].DecodeData(target)
+}
+
+func textOfParts(parts []Part) string {
+	texts := []string{}
+	for _, part := range parts {
+		if part.IsText() {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func partsOfKind(parts []Part, kind string) []Part {
+	result := []Part{}
+	for _, part := range parts {
+		if part.PartKind() == kind {
+			result = append(result, part)
+		}
+	}
+	return result
+}
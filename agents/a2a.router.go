@@ -0,0 +1,159 @@
+package agents
+
+/* NOTE:
+	This A2A protocol implementation is a subset of the A2A specification.
+	IMPORTANT:
+	This is a work in progress and may not cover all aspects of the A2A protocol.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrNoRoute is returned by the A2ARouter when no skill of the registered agents matches the request.
+var ErrNoRoute = errors.New("no agent skill matches the request")
+
+// A2ARoute is the agent and the skill selected by the A2ARouter for a request.
+type A2ARoute struct {
+	AgentBaseURL string
+	Card         AgentCard
+	Skill        map[string]any
+	Score        float64
+}
+
+// SkillID returns the "id" of the selected skill.
+func (route A2ARoute) SkillID() string {
+	id, _ := route.Skill["id"].(string)
+	return id
+}
+
+// SkillMatcher scores a skill of an agent card for a request (0: no match, the highest score wins).
+type SkillMatcher func(request string, card AgentCard, skill map[string]any) float64
+
+// A2ARouter sends the requests to the agent whose skill matches the request best.
+// The agent cards are fetched (and cached) with the A2A client.
+type A2ARouter struct {
+	client  *A2AClient
+	Matcher SkillMatcher // Default: KeywordSkillMatcher
+
+	mutex         sync.Mutex
+	agentBaseURLs []string
+}
+
+// NewA2ARouter creates a router for the agents with the given base URLs.
+func NewA2ARouter(client *A2AClient, agentBaseURLs ...string) *A2ARouter {
+	return &A2ARouter{
+		client:        client,
+		Matcher:       KeywordSkillMatcher,
+		agentBaseURLs: agentBaseURLs,
+	}
+}
+
+// Register adds agents to the registry of the router.
+func (router *A2ARouter) Register(agentBaseURLs ...string) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	router.agentBaseURLs = append(router.agentBaseURLs, agentBaseURLs...)
+}
+
+// Route returns the agent and the skill matching the request best.
+// The unreachable agents are skipped; if no agent is reachable, the errors are returned.
+func (router *A2ARouter) Route(ctx context.Context, request string) (A2ARoute, error) {
+	router.mutex.Lock()
+	agentBaseURLs := append([]string{}, router.agentBaseURLs...)
+	router.mutex.Unlock()
+
+	best := A2ARoute{}
+	var errs []error
+	for _, agentBaseURL := range agentBaseURLs {
+		card, err := router.client.GetAgentCard(ctx, agentBaseURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", agentBaseURL, err))
+			continue
+		}
+		for _, skill := range card.Skills {
+			if score := router.Matcher(request, card, skill); score > best.Score {
+				best = A2ARoute{AgentBaseURL: agentBaseURL, Card: card, Skill: skill, Score: score}
+			}
+		}
+	}
+	if best.Score > 0 {
+		return best, nil
+	}
+	if len(errs) == len(agentBaseURLs) && len(errs) > 0 {
+		return A2ARoute{}, errors.Join(errs...)
+	}
+	return A2ARoute{}, ErrNoRoute
+}
+
+// SendMessage routes the task request with the text of its message, then sends it to the selected agent
+// with the "skill" metadata set to the ID of the selected skill.
+func (router *A2ARouter) SendMessage(ctx context.Context, taskRequest TaskRequest) (TaskResponse, A2ARoute, error) {
+	route, err := router.Route(ctx, taskRequest.Params.Message.Text())
+	if err != nil {
+		return TaskResponse{}, route, err
+	}
+
+	metadata := map[string]any{}
+	for key, value := range taskRequest.Params.MetaData {
+		metadata[key] = value
+	}
+	metadata["skill"] = route.SkillID()
+	taskRequest.Params.MetaData = metadata
+
+	taskResponse, err := router.client.SendMessage(ctx, route.AgentBaseURL, taskRequest)
+	return taskResponse, route, err
+}
+
+// KeywordSkillMatcher scores a skill with the words of the request found in the skill
+// (id, name, description, tags and examples). The words of less than 3 letters and the common words are ignored.
+func KeywordSkillMatcher(request string, card AgentCard, skill map[string]any) float64 {
+	requestWords := skillWords(request)
+	if len(requestWords) == 0 {
+		return 0
+	}
+
+	skillText := strings.Builder{}
+	for _, key := range []string{"id", "name", "description", "tags", "examples"} {
+		if value, ok := skill[key]; ok && value != nil {
+			skillText.WriteString(fmt.Sprint(value) + " ")
+		}
+	}
+	skillWordSet := map[string]bool{}
+	for _, word := range skillWords(skillText.String()) {
+		skillWordSet[word] = true
+	}
+
+	matches := 0
+	for _, word := range requestWords {
+		if skillWordSet[word] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(requestWords))
+}
+
+// skillStopWords are ignored by KeywordSkillMatcher.
+var skillStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true, "about": true,
+	"what": true, "which": true, "who": true, "how": true, "why": true, "when": true, "where": true,
+	"are": true, "was": true, "can": true, "you": true, "your": true, "this": true, "that": true,
+	"would": true, "like": true, "please": true, "its": true, "between": true,
+}
+
+func skillWords(text string) []string {
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 3 && !skillStopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -6,14 +6,27 @@ package agents
 	This is a work in progress and may not cover all aspects of the A2A protocol.
 */
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+)
 
+// StartA2AServer starts the A2A server and blocks until it stops.
 func (agent *Agent) StartA2AServer() error {
-	errListening := http.ListenAndServe(":"+agent.a2aServerConfig.Port, agent.a2aServer)
-	if errListening != nil {
-		return errListening
+	agentServer, err := agent.StartA2AServerAsync()
+	if err != nil {
+		return err
 	}
-	return nil
+	return agentServer.Wait()
+}
+
+// StartA2AServerAsync starts the A2A server in the background and returns it.
+// The running tasks are cancelled when the graceful shutdown of the server times out.
+func (agent *Agent) StartA2AServerAsync() (*AgentServer, error) {
+	if agent.a2aServer == nil {
+		return nil, errors.New("the A2A server is not configured (WithA2AServer)")
+	}
+	return agent.startServer(":"+agent.a2aServerConfig.Port, agent.A2AHandler(), agent.a2aTasks.running.cancelAll, &agent.a2aTasks.background)
 }
 
 // A2AServer returns the A2A server mux
@@ -21,6 +34,11 @@ func (agent *Agent) A2AServer() *http.ServeMux {
 	return agent.a2aServer
 }
 
+// A2AHandler returns the handler of the A2A server with the security of the agent (e.g. for httptest).
+func (agent *Agent) A2AHandler() http.Handler {
+	return agent.secureHandler(agent.a2aServer)
+}
+
 
 func (agent *Agent) A2AServerConfig() A2AServerConfig {
 	return agent.a2aServerConfig
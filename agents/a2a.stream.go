@@ -0,0 +1,117 @@
+package agents
+
+/* NOTE:
+This A2A protocol implementation is a subset of the A2A specification.
+IMPORTANT:
+This is a work in progress and may not cover all aspects of the A2A protocol.
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// streamMessage handles message/stream: it submits the task, then sends the task, its status updates
+// and its artifact updates as Server-Sent Events until the final status update.
+// The task is cancelled when the client disconnects.
+func (agent *Agent) streamMessage(w http.ResponseWriter, r *http.Request, taskRequest TaskRequest) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newJSONRPCErrorResponse(taskRequest.ID, JSONRPCInternalError, "streaming is not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	taskRequest, task, errorResponse := agent.submitTask(r.Context(), taskRequest)
+	if errorResponse != nil {
+		writeSSEEvent(w, flusher, "", errorResponse)
+		return
+	}
+
+	emit := func(event TaskStreamEvent) {
+		writeSSEEvent(w, flusher, "", TaskStreamResponse{
+			JSONRpcVersion: "2.0",
+			ID:             taskRequest.ID,
+			Result:         event,
+		})
+	}
+	emit(TaskStreamEvent{Task: &task})
+
+	finalSent := false
+	taskResponse := agent.runTask(r.Context(), taskRequest, func(event TaskStreamEvent) {
+		finalSent = finalSent || (event.StatusUpdate != nil && event.StatusUpdate.Final)
+		emit(event)
+	})
+	if taskResponse.Error != nil && !finalSent {
+		writeSSEEvent(w, flusher, "", taskResponse)
+	}
+}
+
+// StreamToAgent sends a message to the agent with message/stream (whatever the method of taskRequest) and calls callback for each event
+// (the task, then its status and artifact updates) until the final status update.
+// The streamed content of the answer is in the parts of the artifact updates.
+// If the callback returns an error, the stream is stopped and the error is returned.
+func (agent *Agent) StreamToAgent(agentBaseURL string, taskRequest TaskRequest, callback func(event TaskStreamEvent) error) error {
+	taskRequest.Method = "message/stream"
+	if taskRequest.JSONRpcVersion == "" {
+		taskRequest.JSONRpcVersion = "2.0"
+	}
+	jsonTaskRequest, err := json.Marshal(taskRequest)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, agentBaseURL+"/", bytes.NewReader(jsonTaskRequest))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("failed to send task request: " + resp.Status)
+	}
+
+	return readTaskStream(resp.Body, callback)
+}
+
+// readTaskStream reads the Server-Sent Events of a message/stream response.
+func readTaskStream(body io.Reader, callback func(event TaskStreamEvent) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var streamResponse TaskStreamResponse
+		if err := json.Unmarshal([]byte(data), &streamResponse); err != nil {
+			return err
+		}
+		if streamResponse.Error != nil {
+			return streamResponse.Error
+		}
+		if err := callback(streamResponse.Result); err != nil {
+			return err
+		}
+		if streamResponse.Result.StatusUpdate != nil && streamResponse.Result.StatusUpdate.Final {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,253 @@
+package agents
+
+/* NOTE:
+	This A2A protocol implementation is a subset of the A2A specification.
+	IMPORTANT:
+	This is a work in progress and may not cover all aspects of the A2A protocol.
+*/
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTaskNotFound is returned by a TaskStore when the task does not exist.
+var ErrTaskNotFound = errors.New("task not found")
+
+// TaskStore keeps the A2A tasks of the agent (a task is a Result with the "task" kind).
+// The A2A server uses a MemoryTaskStore by default, see WithA2ATaskStore.
+type TaskStore interface {
+	// Save creates or replaces the task.
+	Save(ctx context.Context, task Result) error
+	// Get returns the task with the given ID, or ErrTaskNotFound.
+	Get(ctx context.Context, id string) (Result, error)
+	// Delete removes the task with the given ID.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryTaskStoreConfig configures the eviction of the tasks of a MemoryTaskStore.
+// Only the tasks done (completed, failed, canceled) or waiting for the client (input-required) are evicted,
+// the submitted and working tasks are always kept.
+type MemoryTaskStoreConfig struct {
+	TTL      time.Duration // The tasks are evicted TTL after their last change (default: 1 hour, negative: never)
+	MaxTasks int           // Over MaxTasks tasks, the oldest tasks are evicted (default: 10000, negative: no limit)
+}
+
+// MemoryTaskStore is an in-memory TaskStore.
+// The tasks are evicted after a TTL or when the store is full (see MemoryTaskStoreConfig).
+type MemoryTaskStore struct {
+	mutex     sync.RWMutex
+	config    MemoryTaskStoreConfig
+	tasks     map[string]storedTask
+	nextSweep time.Time
+}
+
+type storedTask struct {
+	task      Result
+	updatedAt time.Time
+}
+
+// NewMemoryTaskStore creates an in-memory task store with the given eviction configuration.
+func NewMemoryTaskStore(config MemoryTaskStoreConfig) *MemoryTaskStore {
+	if config.TTL == 0 {
+		config.TTL = time.Hour
+	}
+	if config.MaxTasks == 0 {
+		config.MaxTasks = 10000
+	}
+	return &MemoryTaskStore{
+		config: config,
+		tasks:  make(map[string]storedTask),
+	}
+}
+
+func (store *MemoryTaskStore) Save(ctx context.Context, task Result) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := time.Now()
+	store.tasks[task.ID] = storedTask{task: cloneTask(task), updatedAt: now}
+	store.evict(now)
+	return nil
+}
+
+func (store *MemoryTaskStore) Get(ctx context.Context, id string) (Result, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	stored, ok := store.tasks[id]
+	if !ok || store.expired(stored, time.Now()) {
+		return Result{}, ErrTaskNotFound
+	}
+	return cloneTask(stored.task), nil
+}
+
+func (store *MemoryTaskStore) Delete(ctx context.Context, id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.tasks, id)
+	return nil
+}
+
+// evictable reports whether the task can be evicted: it is done or waiting for the client.
+func evictable(task Result) bool {
+	return task.Status.IsTerminal() || task.Status.State == TaskStateInputRequired
+}
+
+func (store *MemoryTaskStore) expired(stored storedTask, now time.Time) bool {
+	return store.config.TTL > 0 && evictable(stored.task) && now.Sub(stored.updatedAt) > store.config.TTL
+}
+
+// evict removes the expired tasks, then the oldest evictable tasks while the store is full.
+// NOTE: the expired tasks are removed at most every minute (Get ignores them in the meantime).
+func (store *MemoryTaskStore) evict(now time.Time) {
+	if store.config.TTL > 0 && !now.Before(store.nextSweep) {
+		for id, stored := range store.tasks {
+			if store.expired(stored, now) {
+				delete(store.tasks, id)
+			}
+		}
+		store.nextSweep = now.Add(min(store.config.TTL, time.Minute))
+	}
+	if store.config.MaxTasks < 0 || len(store.tasks) <= store.config.MaxTasks {
+		return
+	}
+
+	oldest := []string{}
+	for id, stored := range store.tasks {
+		if evictable(stored.task) {
+			oldest = append(oldest, id)
+		}
+	}
+	sort.Slice(oldest, func(i, j int) bool {
+		return store.tasks[oldest[i]].updatedAt.Before(store.tasks[oldest[j]].updatedAt)
+	})
+	for _, id := range oldest[:min(len(oldest), len(store.tasks)-store.config.MaxTasks)] {
+		delete(store.tasks, id)
+	}
+}
+
+// cloneTask returns a deep copy of the task, so the stored tasks never share their messages,
+// parts or metadata with the tasks of the callers.
+func cloneTask(task Result) Result {
+	if task.Status.Message != nil {
+		message := cloneMessage(*task.Status.Message)
+		task.Status.Message = &message
+	}
+	if task.History != nil {
+		history := make([]AgentMessage, len(task.History))
+		for i, message := range task.History {
+			history[i] = cloneMessage(message)
+		}
+		task.History = history
+	}
+	if task.Artifacts != nil {
+		artifacts := make([]Artifact, len(task.Artifacts))
+		for i, artifact := range task.Artifacts {
+			artifact.Parts = cloneParts(artifact.Parts)
+			artifacts[i] = artifact
+		}
+		task.Artifacts = artifacts
+	}
+	task.Metadata = cloneJSONObject(task.Metadata)
+	return task
+}
+
+func cloneMessage(message AgentMessage) AgentMessage {
+	message.Parts = cloneParts(message.Parts)
+	return message
+}
+
+func cloneParts(parts []Part) []Part {
+	if parts == nil {
+		return nil
+	}
+	clones := make([]Part, len(parts))
+	for i, part := range parts {
+		if part.File != nil {
+			file := *part.File
+			part.File = &file
+		}
+		part.Data = cloneJSONObject(part.Data)
+		part.Metadata = cloneJSONObject(part.Metadata)
+		clones[i] = part
+	}
+	return clones
+}
+
+// cloneJSONObject copies a JSON object, the nested objects and arrays included.
+// NOTE: the other values (e.g. structs) are copied as is.
+func cloneJSONObject(object map[string]any) map[string]any {
+	if object == nil {
+		return nil
+	}
+	clone := make(map[string]any, len(object))
+	for key, value := range object {
+		clone[key] = cloneJSONValue(value)
+	}
+	return clone
+}
+
+func cloneJSONValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		return cloneJSONObject(value)
+	case []any:
+		clone := make([]any, len(value))
+		for i, item := range value {
+			clone[i] = cloneJSONValue(item)
+		}
+		return clone
+	}
+	return value
+}
+
+// a2aTasks manages the lifecycle of the A2A tasks: the store and the running tasks.
+// The mutex serializes the state changes of the tasks (e.g. a task cancelled while it completes).
+// background tracks the non-blocking tasks, the graceful shutdown of the A2A server waits for them.
+type a2aTasks struct {
+	mutex      sync.Mutex
+	store      TaskStore
+	running    *httpCompletions
+	background sync.WaitGroup
+}
+
+func (agent *Agent) ensureA2ATasks() *a2aTasks {
+	if agent.a2aTasks == nil {
+		agent.a2aTasks = &a2aTasks{
+			store:   NewMemoryTaskStore(MemoryTaskStoreConfig{}),
+			running: newHTTPCompletions(),
+		}
+	}
+	return agent.a2aTasks
+}
+
+// setState changes the task with update and saves it.
+// A task in a terminal state (e.g. canceled) is never changed: it returns the stored task and false.
+// If update fails, the task is not saved.
+func (tasks *a2aTasks) setState(ctx context.Context, id string, update func(task *Result) error) (Result, bool, error) {
+	tasks.mutex.Lock()
+	defer tasks.mutex.Unlock()
+
+	task, err := tasks.store.Get(ctx, id)
+	if err != nil {
+		return Result{}, false, err
+	}
+	if task.Status.IsTerminal() {
+		return task, false, nil
+	}
+	if err := update(&task); err != nil {
+		return task, false, err
+	}
+	task.Status.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	return task, true, tasks.store.Save(ctx, task)
+}
+
+// truncateHistory keeps the last historyLength messages of the task history (nil: all the messages).
+func truncateHistory(task Result, historyLength *int) Result {
+	if historyLength != nil && *historyLength >= 0 && len(task.History) > *historyLength {
+		task.History = task.History[len(task.History)-*historyLength:]
+	}
+	return task
+}
@@ -0,0 +1,108 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// a2aToolApprovalMetadataKey is the key of the pending tool approval in the metadata of an input-required task.
+const a2aToolApprovalMetadataKey = "toolApproval"
+
+// a2aToolApprovalRetriesMetadataKey is the key of the number of approvals asked again in a row in the metadata of the task:
+// the answer of the client did not apply because the agent callback ran another tool call (e.g. the model changed the arguments).
+const a2aToolApprovalRetriesMetadataKey = "toolApprovalRetries"
+
+// A2AMaxToolApprovalRetries is the maximum number of approvals asked again in a row for a task:
+// beyond, the task fails instead of asking the client again.
+const A2AMaxToolApprovalRetries = 2
+
+// a2aToolApprovalAnswers are the answers approving a tool call (case insensitive), the other answers deny it.
+var a2aToolApprovalAnswers = []string{"yes", "y", "ok", "approve", "approved"}
+
+type a2aToolApprovalKey struct{}
+
+// a2aToolApprovalAnswer is the answer of the A2A client to the pending tool approval of a task.
+// used is set when the answer applies to a tool call.
+type a2aToolApprovalAnswer struct {
+	request ToolApprovalRequest
+	answer  string
+	used    atomic.Bool
+}
+
+// A2AToolApprover returns an approver for the agents served with WithA2AServer.
+// When a tool call needs an approval, the A2A task goes to the input-required state, with the question
+// as status message (and the ToolApprovalRequest in the "toolApproval" metadata of the task):
+// the agent callback must return the error of Run (or RunStream), a ToolApprovalRequiredError.
+// The client answers with a message to the task ("yes" to approve, anything else to deny),
+// and the agent callback runs again: the same tool call (same name and arguments) gets the answer.
+// If the agent callback runs another tool call (e.g. the model changed the arguments), the approval is asked again,
+// at most A2AMaxToolApprovalRetries times in a row: then the task fails.
+func A2AToolApprover() ToolApprover {
+	return func(ctx context.Context, request ToolApprovalRequest) (ToolApproval, error) {
+		if pending, ok := ctx.Value(a2aToolApprovalKey{}).(*a2aToolApprovalAnswer); ok && pending.matches(request) {
+			pending.used.Store(true)
+			answer := strings.ToLower(strings.TrimSpace(pending.answer))
+			if slices.Contains(a2aToolApprovalAnswers, strings.TrimRight(answer, ".!")) {
+				return ToolApproval{Approved: true}, nil
+			}
+			return ToolApproval{Reason: "the user answered: " + pending.answer}, nil
+		}
+		return ToolApproval{}, &ToolApprovalRequiredError{Request: request}
+	}
+}
+
+// matches returns true if the request is the tool call of the pending approval.
+func (pending *a2aToolApprovalAnswer) matches(request ToolApprovalRequest) bool {
+	pendingArgs, _ := json.Marshal(pending.request.Arguments)
+	requestArgs, _ := json.Marshal(request.Arguments)
+	return pending.request.ToolName == request.ToolName && string(pendingArgs) == string(requestArgs)
+}
+
+// requireA2AToolApproval moves the task to the input-required state with the question about the tool call.
+// retries is the number of approvals asked again in a row (see A2AMaxToolApprovalRetries).
+func requireA2AToolApproval(task *Result, request ToolApprovalRequest, retries int) {
+	arguments, _ := json.Marshal(request.Arguments)
+	question := AgentMessage{
+		Role:      "agent",
+		Parts:     []Part{NewTextPart(fmt.Sprintf("Do you approve the call of the tool %s with the arguments %s? (yes/no)", request.ToolName, arguments))},
+		TaskID:    task.ID,
+		ContextID: task.ContextID,
+	}
+	task.Status = TaskStatus{State: TaskStateInputRequired, Message: &question}
+	task.History = append(task.History, question)
+	task.Metadata = maps.Clone(task.Metadata)
+	if task.Metadata == nil {
+		task.Metadata = map[string]any{}
+	}
+	task.Metadata[a2aToolApprovalMetadataKey] = request
+	task.Metadata[a2aToolApprovalRetriesMetadataKey] = retries
+}
+
+// takeA2AToolApproval removes the pending tool approval from the metadata of the task and returns it,
+// with the number of approvals asked again in a row.
+func takeA2AToolApproval(task *Result) (ToolApprovalRequest, int, bool) {
+	var request ToolApprovalRequest
+	var retries int
+	pending, ok := task.Metadata[a2aToolApprovalMetadataKey]
+	if !ok {
+		return request, retries, false
+	}
+	task.Metadata = maps.Clone(task.Metadata)
+	delete(task.Metadata, a2aToolApprovalMetadataKey)
+
+	// NOTE: the request and the retries are decoded from JSON when the task store is persistent
+	data, _ := json.Marshal(task.Metadata[a2aToolApprovalRetriesMetadataKey])
+	json.Unmarshal(data, &retries)
+	delete(task.Metadata, a2aToolApprovalRetriesMetadataKey)
+
+	data, err := json.Marshal(pending)
+	if err != nil || json.Unmarshal(data, &request) != nil {
+		return request, retries, false
+	}
+	return request, retries, true
+}
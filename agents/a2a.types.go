@@ -6,6 +6,11 @@ package agents
 	This is a work in progress and may not cover all aspects of the A2A protocol.
 */
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // AgentCard represents the metadata for this agent
 type AgentCard struct {
 	Name         string           `json:"name"`
@@ -17,8 +22,21 @@ type AgentCard struct {
 }
 
 type AgentMessageParams struct {
-	Message  AgentMessage   `json:"message"`
-	MetaData map[string]any `json:"metadata,omitempty"` // Optional, for additional metadata
+	Message       AgentMessage              `json:"message,omitzero"`
+	Configuration *MessageSendConfiguration `json:"configuration,omitempty"` // Optional, for message/send
+	MetaData      map[string]any            `json:"metadata,omitempty"`      // Optional, for additional metadata
+
+	// Parameters of tasks/get and tasks/cancel
+	ID            string `json:"id,omitempty"`            // ID of the task
+	HistoryLength *int   `json:"historyLength,omitempty"` // Optional, number of recent messages of the task history to return
+}
+
+// MessageSendConfiguration represents the configuration of a message/send request
+type MessageSendConfiguration struct {
+	// Blocking: wait for the end of the task (default: true).
+	// With false, the task is returned as soon as it is submitted; use tasks/get to poll it.
+	Blocking      *bool `json:"blocking,omitempty"`
+	HistoryLength *int  `json:"historyLength,omitempty"` // Optional, number of recent messages of the task history to return
 }
 
 // REF: https://google-a2a.github.io/A2A/specification/#92-basic-execution-synchronous-polling-style
@@ -32,31 +50,43 @@ type TaskRequest struct {
 
 // Message represents a message structure
 type AgentMessage struct {
-	Role      string     `json:"role,omitempty"`
-	Parts     []TextPart `json:"parts"`
-	MessageID string     `json:"messageId,omitempty"` // Optional, for storing message ID
-	TaskID    string     `json:"taskId,omitempty"`    // Optional, for storing task ID
-	ContextID string     `json:"contextId,omitempty"` // Optional, for storing context ID
+	Role      string `json:"role,omitempty"`
+	Parts     []Part `json:"parts"`
+	MessageID string `json:"messageId,omitempty"` // Optional, for storing message ID
+	TaskID    string `json:"taskId,omitempty"`    // Optional, for storing task ID
+	ContextID string `json:"contextId,omitempty"` // Optional, for storing context ID
 }
 
-// TextPart represents a text part of a message
-type TextPart struct {
-	Text string `json:"text"`
-	Type string `json:"type"` // Should be "text" for text parts
-}
+
+// States of a task
+const (
+	TaskStateSubmitted     = "submitted"
+	TaskStateWorking       = "working"
+	TaskStateInputRequired = "input-required"
+	TaskStateCompleted     = "completed"
+	TaskStateFailed        = "failed"
+	TaskStateCanceled      = "canceled"
+)
 
 // TaskStatus represents the status of a task
 type TaskStatus struct {
-	State string `json:"state"`
+	State     string        `json:"state"`
+	Message   *AgentMessage `json:"message,omitempty"`   // Optional, message of the agent about the state (e.g. the question of input-required)
+	Timestamp string        `json:"timestamp,omitempty"` // Optional, time of the last state change (RFC 3339)
+}
+
+// IsTerminal reports whether the task is done (completed, failed or canceled).
+func (status TaskStatus) IsTerminal() bool {
+	return status.State == TaskStateCompleted || status.State == TaskStateFailed || status.State == TaskStateCanceled
 }
 
 // TODO: make the response compliant with the A2A protocol
 // REF: https://google-a2a.github.io/A2A/specification/#92-basic-execution-synchronous-polling-style
 
 type Artifact struct {
-	ArtifactID string     `json:"artifactId"`
-	Name       string     `json:"name"`
-	Parts      []TextPart `json:"parts"` // Parts of the artifact, e.g., text, images, etc.
+	ArtifactID string `json:"artifactId"`
+	Name       string `json:"name"`
+	Parts      []Part `json:"parts"` // Parts of the artifact: text, files and data
 }
 
 type Result struct {
@@ -71,8 +101,101 @@ type Result struct {
 
 // TaskResponse represents the response task structure
 type TaskResponse struct {
-	JSONRpcVersion string `json:"jsonrpc"` // Should be "2.0"
-	ID             string `json:"id"`
-	Result         Result `json:"result"` // The result of the task execution
+	JSONRpcVersion string        `json:"jsonrpc"` // Should be "2.0"
+	ID             string        `json:"id"`
+	Result         Result        `json:"result,omitzero"` // The result of the task execution
+	Error          *JSONRPCError `json:"error,omitempty"` // The error, instead of the result
+}
+
+// TaskStatusUpdateEvent is sent by message/stream when the status of the task changes.
+// Final is true for the last event of the stream.
+type TaskStatusUpdateEvent struct {
+	TaskID    string         `json:"taskId"`
+	ContextID string         `json:"contextId"`
+	Kind      string         `json:"kind"` // Should be "status-update"
+	Status    TaskStatus     `json:"status"`
+	Final     bool           `json:"final"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// TaskArtifactUpdateEvent is sent by message/stream with an artifact, or a chunk of an artifact (Append: true
+// when the parts must be appended to the previous parts of the artifact).
+type TaskArtifactUpdateEvent struct {
+	TaskID    string         `json:"taskId"`
+	ContextID string         `json:"contextId"`
+	Kind      string         `json:"kind"` // Should be "artifact-update"
+	Artifact  Artifact       `json:"artifact"`
+	Append    bool           `json:"append,omitempty"`
+	LastChunk bool           `json:"lastChunk,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// TaskStreamEvent is the result of a message/stream event: the task, a status update or an artifact update.
+// Only one of the fields is set.
+type TaskStreamEvent struct {
+	Task           *Result
+	StatusUpdate   *TaskStatusUpdateEvent
+	ArtifactUpdate *TaskArtifactUpdateEvent
+}
+
+func (event TaskStreamEvent) MarshalJSON() ([]byte, error) {
+	switch {
+	case event.Task != nil:
+		return json.Marshal(event.Task)
+	case event.StatusUpdate != nil:
+		return json.Marshal(event.StatusUpdate)
+	case event.ArtifactUpdate != nil:
+		return json.Marshal(event.ArtifactUpdate)
+	}
+	return []byte("null"), nil
+}
+
+func (event *TaskStreamEvent) UnmarshalJSON(data []byte) error {
+	var kind struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return err
+	}
+	switch kind.Kind {
+	case "status-update":
+		event.StatusUpdate = &TaskStatusUpdateEvent{}
+		return json.Unmarshal(data, event.StatusUpdate)
+	case "artifact-update":
+		event.ArtifactUpdate = &TaskArtifactUpdateEvent{}
+		return json.Unmarshal(data, event.ArtifactUpdate)
+	default:
+		event.Task = &Result{}
+		return json.Unmarshal(data, event.Task)
+	}
+}
+
+// TaskStreamResponse represents an event of a message/stream response
+type TaskStreamResponse struct {
+	JSONRpcVersion string          `json:"jsonrpc"` // Should be "2.0"
+	ID             string          `json:"id"`
+	Result         TaskStreamEvent `json:"result,omitzero"`
+	Error          *JSONRPCError   `json:"error,omitempty"`
+}
+
+// JSON-RPC error codes
+const (
+	JSONRPCParseError         = -32700
+	JSONRPCInvalidRequest     = -32600
+	JSONRPCMethodNotFound     = -32601
+	JSONRPCInvalidParams      = -32602
+	JSONRPCInternalError      = -32603
+	A2ATaskNotFoundError      = -32001
+	A2ATaskNotCancelableError = -32002
+)
+
+// JSONRPCError represents a JSON-RPC error object
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
 
+func (err *JSONRPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", err.Code, err.Message)
 }
@@ -0,0 +1,137 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newSkilledAgent(t *testing.T, name string, skills []map[string]any) *httptest.Server {
+	agent, err := NewAgent(name,
+		WithA2AServer(A2AServerConfig{}),
+		WithAgentCard(AgentCard{Name: name, Skills: skills}),
+		WithAgentCallback(func(ctx *AgentCallbackContext) (TaskResponse, error) {
+			return a2aAnswer(name+": "+ctx.TaskRequest.Params.MetaData["skill"].(string), ""), nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(agent.A2AHandler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// go test -v -run TestA2AClient
+func TestA2AClient(t *testing.T) {
+	bob := newSkilledAgent(t, "Bob", nil)
+
+	// Proxy failing the first request of each kind, and counting the agent card requests
+	var cardRequests, failures atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/.well-known/agent.json" {
+			cardRequests.Add(1)
+		}
+		if failures.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		request, _ := http.NewRequestWithContext(r.Context(), r.Method, bob.URL+r.URL.Path, r.Body)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer response.Body.Close()
+		w.WriteHeader(response.StatusCode)
+		buffer := make([]byte, 32*1024)
+		for {
+			n, err := response.Body.Read(buffer)
+			w.Write(buffer[:n])
+			if err != nil {
+				return
+			}
+		}
+	}))
+	defer proxy.Close()
+
+	client := NewA2AClient(A2AClientConfig{BearerToken: "secret", RetryBackoff: time.Millisecond})
+
+	// Retried after the 503, then cached
+	for range 2 {
+		card, err := client.GetAgentCard(context.Background(), proxy.URL)
+		if err != nil || card.Name != "Bob" {
+			t.Fatalf("😡 Failed to get the agent card: %v %+v", err, card)
+		}
+	}
+	if cardRequests.Load() != 2 {
+		t.Errorf("😡 Expected 2 agent card requests (failure and retry), got %d", cardRequests.Load())
+	}
+
+	request := a2aMessage("Hello", "")
+	request.Params.MetaData = map[string]any{"skill": "hello"}
+	response, err := client.SendMessage(context.Background(), proxy.URL, request)
+	if err != nil || response.Result.History[0].Parts[0].Text != "Bob: hello" {
+		t.Errorf("😡 Unexpected response: %v %+v", err, response)
+	}
+	_, err = client.GetTask(context.Background(), proxy.URL, "unknown")
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != A2ATaskNotFoundError {
+		t.Errorf("😡 Expected a task not found error, got %v", err)
+	}
+
+	// The messages are not retried, unless RetryMessages is set
+	failures.Store(0)
+	if _, err := client.SendMessage(context.Background(), proxy.URL, request); err == nil {
+		t.Errorf("😡 Expected the message/send request not to be retried")
+	}
+	retrying := NewA2AClient(A2AClientConfig{BearerToken: "secret", RetryBackoff: time.Millisecond, RetryMessages: true})
+	failures.Store(0)
+	if _, err := retrying.SendMessage(context.Background(), proxy.URL, request); err != nil {
+		t.Errorf("😡 Expected the message/send request to be retried, got %v", err)
+	}
+
+	// No retry for the client errors
+	unauthorized := NewA2AClient(A2AClientConfig{RetryBackoff: time.Millisecond})
+	failures.Store(1)
+	if _, err := unauthorized.GetAgentCard(context.Background(), proxy.URL); err == nil {
+		t.Errorf("😡 Expected an unauthorized error")
+	}
+}
+
+// go test -v -run TestA2ARouter
+func TestA2ARouter(t *testing.T) {
+	pizzaiolo := newSkilledAgent(t, "Pizzaiolo", []map[string]any{
+		{"id": "order_pizza", "name": "Order a pizza", "description": "Order a pizza with toppings", "tags": []string{"food", "pizza"}},
+	})
+	captain := newSkilledAgent(t, "Captain", []map[string]any{
+		{"id": "star_trek_expert", "name": "Star Trek expert", "description": "Answer questions about the Star Trek universe and its starships"},
+		{"id": "navigation", "name": "Navigation", "description": "Compute a route between two star systems"},
+	})
+
+	router := NewA2ARouter(NewA2AClient(A2AClientConfig{RetryBackoff: time.Millisecond}), pizzaiolo.URL)
+	router.Register(captain.URL, "http://127.0.0.1:1") // unreachable agent
+
+	route, err := router.Route(context.Background(), "Which starships appear in Star Trek?")
+	if err != nil || route.AgentBaseURL != captain.URL || route.SkillID() != "star_trek_expert" {
+		t.Errorf("😡 Unexpected route: %v %+v", err, route)
+	}
+
+	request := a2aMessage("I would like a pizza with mushrooms", "")
+	response, route, err := router.SendMessage(context.Background(), request)
+	if err != nil || route.Card.Name != "Pizzaiolo" || response.Result.History[0].Parts[0].Text != "Pizzaiolo: order_pizza" {
+		t.Errorf("😡 Unexpected response: %v %+v", err, response)
+	}
+
+	if _, err := router.Route(context.Background(), "What is the weather like?"); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("😡 Expected no route, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package agents
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// go test -v -run TestA2AParts
+func TestA2AParts(t *testing.T) {
+	type Extraction struct {
+		Name  string   `json:"name"`
+		Ships []string `json:"ships"`
+	}
+	dataPart, err := NewDataPart(Extraction{Name: "Kirk", Ships: []string{"Enterprise"}})
+	if err != nil {
+		t.Fatalf("😡 Failed to create the data part: %v", err)
+	}
+	message := AgentMessage{
+		Role: "user",
+		Parts: []Part{
+			NewTextPart("Hello"),
+			NewFilePart("report.txt", "text/plain", []byte("Captain's log")),
+			NewFilePartFromURI("logo.png", "image/png", "https://budgie.dev/logo.png"),
+			dataPart,
+			{Text: "World", Type: "text"}, // Previous format
+		},
+	}
+
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("😡 Failed to marshal the message: %v", err)
+	}
+	var decoded AgentMessage
+	if err := json.Unmarshal(jsonData, &decoded); err != nil {
+		t.Fatalf("😡 Failed to unmarshal the message: %v", err)
+	}
+
+	if decoded.Text() != "Hello\nWorld" {
+		t.Errorf("😡 Unexpected text: %q", decoded.Text())
+	}
+	files := decoded.FileParts()
+	content, err := files[0].FileBytes()
+	if len(files) != 2 || err != nil || string(content) != "Captain's log" || files[0].File.MimeType != "text/plain" {
+		t.Errorf("😡 Unexpected file parts: %v %+v", err, files)
+	}
+	if _, err := files[1].FileBytes(); err == nil || files[1].File.URI != "https://budgie.dev/logo.png" {
+		t.Errorf("😡 Expected a file referenced by its URI: %+v", files[1].File)
+	}
+	var extraction Extraction
+	if err := decoded.DataParts()[0].DecodeData(&extraction); err != nil || extraction.Ships[0] != "Enterprise" {
+		t.Errorf("😡 Unexpected data: %v %+v", err, extraction)
+	}
+
+	// Serialization of the kinds
+	var raw struct {
+		Parts []map[string]any `json:"parts"`
+	}
+	json.Unmarshal(jsonData, &raw)
+	for i, kind := range []string{"text", "file", "file", "data", "text"} {
+		if raw.Parts[i]["kind"] != kind {
+			t.Errorf("😡 Expected the kind %s, got %v", kind, raw.Parts[i])
+		}
+	}
+	if _, ok := raw.Parts[3]["text"]; ok {
+		t.Errorf("😡 Unexpected text in a data part: %v", raw.Parts[3])
+	}
+
+	// A data part must be a JSON object
+	if _, err := NewDataPart([]string{"Kirk"}); err == nil {
+		t.Errorf("😡 Expected an error for a data part which is not an object")
+	}
+}
+
+// go test -v -run TestA2ADataPartsExchange
+func TestA2ADataPartsExchange(t *testing.T) {
+	bob, err := NewAgent("Bob",
+		WithA2AServer(A2AServerConfig{}),
+		WithAgentCallback(func(ctx *AgentCallbackContext) (TaskResponse, error) {
+			var request map[string]any
+			if err := ctx.MessageData(&request); err != nil {
+				return TaskResponse{}, err
+			}
+			result, err := NewDataPart(map[string]any{"captain": request["ship"] == "Enterprise"})
+			if err != nil {
+				return TaskResponse{}, err
+			}
+			return TaskResponse{
+				Result: Result{
+					Artifacts: []Artifact{{ArtifactID: "1", Name: "extraction", Parts: []Part{result}}},
+				},
+			}, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.A2AHandler())
+	defer server.Close()
+
+	dataPart, _ := NewDataPart(map[string]any{"ship": "Enterprise"})
+	request := a2aMessage("", "")
+	request.Params.Message.Parts = []Part{dataPart}
+	response, err := bob.SendToAgent(server.URL, request)
+	if err != nil {
+		t.Fatalf("😡 Failed to send the message: %v", err)
+	}
+
+	var extraction struct {
+		Captain bool `json:"captain"`
+	}
+	if err := response.Result.Artifacts[0].DataParts()[0].DecodeData(&extraction); err != nil || !extraction.Captain {
+		t.Errorf("😡 Unexpected extraction: %v %+v", err, response.Result.Artifacts)
+	}
+}
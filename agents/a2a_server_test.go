@@ -0,0 +1,252 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func a2aMessage(text string, taskID string) TaskRequest {
+	return TaskRequest{
+		JSONRpcVersion: "2.0",
+		ID:             "1",
+		Method:         "message/send",
+		Params: AgentMessageParams{
+			Message: AgentMessage{
+				Role:   "user",
+				Parts:  []TextPart{{Text: text, Type: "text"}},
+				TaskID: taskID,
+			},
+		},
+	}
+}
+
+func a2aAnswer(text string, state string) TaskResponse {
+	return TaskResponse{
+		Result: Result{
+			Status:  TaskStatus{State: state},
+			History: []AgentMessage{{Role: "assistant", Parts: []TextPart{{Text: text, Type: "text"}}}},
+		},
+	}
+}
+
+// go test -v -run TestA2ATaskLifecycle
+func TestA2ATaskLifecycle(t *testing.T) {
+	started := make(chan struct{}, 1)
+	bob, err := NewAgent("Bob",
+		WithA2AServer(A2AServerConfig{}),
+		WithAgentCallback(func(ctx *AgentCallbackContext) (TaskResponse, error) {
+			switch ctx.TaskRequest.Params.Message.Parts[0].Text {
+			case "research":
+				// Long-running task: wait for the cancellation
+				started <- struct{}{}
+				<-ctx.Context.Done()
+				return TaskResponse{}, ctx.Context.Err()
+			case "order a pizza":
+				return a2aAnswer("Which pizza?", TaskStateInputRequired), nil
+			case "fail":
+				return TaskResponse{}, errors.New("boom")
+			default:
+				return a2aAnswer("Hello", ""), nil
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.A2AHandler())
+	defer server.Close()
+	client := NewA2AClient(A2AClientConfig{})
+
+	// Blocking message
+	response, err := bob.SendToAgent(server.URL, a2aMessage("Hello", ""))
+	if err != nil {
+		t.Fatalf("😡 Failed to send the message: %v", err)
+	}
+	if response.Result.Status.State != TaskStateCompleted || response.Result.History[0].Parts[0].Text != "Hello" {
+		t.Errorf("😡 Unexpected response: %+v", response)
+	}
+	task, err := client.GetTask(context.Background(), server.URL, response.Result.ID)
+	if err != nil || task.Result.Status.State != TaskStateCompleted || len(task.Result.History) != 2 {
+		t.Errorf("😡 Unexpected task: %v %+v", err, task)
+	}
+
+	// Input required, then continue the task
+	response, _ = bob.SendToAgent(server.URL, a2aMessage("order a pizza", ""))
+	if response.Result.Status.State != TaskStateInputRequired {
+		t.Errorf("😡 Expected input-required, got %s", response.Result.Status.State)
+	}
+	response, err = bob.SendToAgent(server.URL, a2aMessage("a margherita", response.Result.ID))
+	if err != nil || response.Result.Status.State != TaskStateCompleted {
+		t.Errorf("😡 Expected the task to be completed: %v %+v", err, response)
+	}
+	_, err = bob.SendToAgent(server.URL, a2aMessage("another one", response.Result.ID))
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != JSONRPCInvalidParams {
+		t.Errorf("😡 Expected an invalid params error for a completed task, got %v", err)
+	}
+
+	// Failed task
+	response, err = bob.SendToAgent(server.URL, a2aMessage("fail", ""))
+	if err != nil || response.Result.Status.State != TaskStateFailed || response.Result.Status.Message.Text() != "boom" {
+		t.Errorf("😡 Expected the failed task: %v %+v", err, response)
+	}
+	task, _ = client.GetTask(context.Background(), server.URL, response.Result.ID)
+	if task.Result.Status.State != TaskStateFailed {
+		t.Errorf("😡 Expected the task to be failed, got %s", task.Result.Status.State)
+	}
+
+	// Non-blocking task, then cancel it
+	blocking := false
+	request := a2aMessage("research", "")
+	request.Params.Configuration = &MessageSendConfiguration{Blocking: &blocking}
+	response, err = bob.SendToAgent(server.URL, request)
+	if err != nil || response.Result.Status.State != TaskStateSubmitted {
+		t.Fatalf("😡 Expected a submitted task: %v %+v", err, response)
+	}
+	<-started
+	task, _ = client.GetTask(context.Background(), server.URL, response.Result.ID)
+	if task.Result.Status.State != TaskStateWorking {
+		t.Errorf("😡 Expected a working task, got %s", task.Result.Status.State)
+	}
+	task, err = client.CancelTask(context.Background(), server.URL, response.Result.ID)
+	if err != nil || task.Result.Status.State != TaskStateCanceled {
+		t.Errorf("😡 Expected the task to be canceled: %v %+v", err, task)
+	}
+	_, err = client.CancelTask(context.Background(), server.URL, response.Result.ID)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != A2ATaskNotCancelableError {
+		t.Errorf("😡 Expected a task not cancelable error, got %v", err)
+	}
+	// The callback stops, the task stays canceled
+	time.Sleep(20 * time.Millisecond)
+	task, _ = client.GetTask(context.Background(), server.URL, response.Result.ID)
+	if task.Result.Status.State != TaskStateCanceled {
+		t.Errorf("😡 Expected the task to stay canceled, got %s", task.Result.Status.State)
+	}
+
+	// Errors
+	_, err = client.GetTask(context.Background(), server.URL, "unknown")
+	if !errors.As(err, &rpcErr) || rpcErr.Code != A2ATaskNotFoundError {
+		t.Errorf("😡 Expected a task not found error, got %v", err)
+	}
+	_, err = bob.SendToAgent(server.URL, TaskRequest{JSONRpcVersion: "2.0", ID: "1", Method: "tasks/unknown"})
+	if !errors.As(err, &rpcErr) || rpcErr.Code != JSONRPCMethodNotFound {
+		t.Errorf("😡 Expected a method not found error, got %v", err)
+	}
+}
+
+// go test -v -run TestA2AMessageStream
+func TestA2AMessageStream(t *testing.T) {
+	bob, err := NewAgent("Bob",
+		WithA2AServer(A2AServerConfig{}),
+		WithAgentCallback(func(ctx *AgentCallbackContext) (TaskResponse, error) {
+			answer := ""
+			for _, chunk := range []string{"Hello", " World"} {
+				if err := ctx.StreamContent(chunk); err != nil {
+					return TaskResponse{}, err
+				}
+				answer += chunk
+			}
+			return a2aAnswer(answer, ""), nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.A2AHandler())
+	defer server.Close()
+
+	events := []TaskStreamEvent{}
+	streamed := ""
+	err = bob.StreamToAgent(server.URL, a2aMessage("Hello", ""), func(event TaskStreamEvent) error {
+		events = append(events, event)
+		if event.ArtifactUpdate != nil {
+			streamed += event.ArtifactUpdate.Artifact.Parts[0].Text
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to stream the message: %v", err)
+	}
+
+	// task, working, 2 chunks, completed
+	if len(events) != 5 {
+		t.Fatalf("😡 Expected 5 events, got %d", len(events))
+	}
+	if events[0].Task == nil || events[0].Task.Status.State != TaskStateSubmitted {
+		t.Errorf("😡 Expected the submitted task first, got %+v", events[0])
+	}
+	if events[1].StatusUpdate == nil || events[1].StatusUpdate.Status.State != TaskStateWorking {
+		t.Errorf("😡 Expected the working status, got %+v", events[1])
+	}
+	if streamed != "Hello World" || events[2].ArtifactUpdate.Append || !events[3].ArtifactUpdate.Append {
+		t.Errorf("😡 Unexpected artifact updates: %q", streamed)
+	}
+	final := events[4].StatusUpdate
+	if final == nil || !final.Final || final.Status.State != TaskStateCompleted || final.Status.Message.Parts[0].Text != "Hello World" {
+		t.Errorf("😡 Unexpected final event: %+v", events[4])
+	}
+
+	// The streamed answer is kept as an artifact of the task
+	task, _ := NewA2AClient(A2AClientConfig{}).GetTask(context.Background(), server.URL, final.TaskID)
+	if len(task.Result.Artifacts) != 1 || task.Result.Artifacts[0].Parts[0].Text != "Hello World" {
+		t.Errorf("😡 Unexpected artifacts: %+v", task.Result.Artifacts)
+	}
+}
+
+// go test -v -run TestMemoryTaskStoreDeepCopy
+func TestMemoryTaskStoreDeepCopy(t *testing.T) {
+	store := NewMemoryTaskStore(MemoryTaskStoreConfig{})
+	task := Result{
+		ID:       "task-1",
+		Status:   TaskStatus{State: TaskStateCompleted, Message: &AgentMessage{Role: "agent", Parts: []TextPart{{Text: "done", Type: "text"}}}},
+		History:  []AgentMessage{{Role: "user", Parts: []TextPart{{Text: "Hello", Type: "text"}}}},
+		Metadata: map[string]any{"tags": []any{"a"}, "origin": map[string]any{"name": "bob"}},
+	}
+	if err := store.Save(context.Background(), task); err != nil {
+		t.Fatalf("😡 Failed to save the task: %v", err)
+	}
+
+	// Changing the saved task or a task returned by Get does not change the stored task
+	task.Status.Message.Parts[0].Text = "changed"
+	task.Metadata["origin"].(map[string]any)["name"] = "changed"
+	stored, _ := store.Get(context.Background(), "task-1")
+	stored.History[0].Parts[0].Text = "changed"
+	stored.Metadata["tags"].([]any)[0] = "changed"
+
+	stored, _ = store.Get(context.Background(), "task-1")
+	if stored.Status.Message.Parts[0].Text != "done" || stored.History[0].Parts[0].Text != "Hello" {
+		t.Errorf("😡 Expected the stored messages to be unchanged, got %+v", stored)
+	}
+	if stored.Metadata["origin"].(map[string]any)["name"] != "bob" || stored.Metadata["tags"].([]any)[0] != "a" {
+		t.Errorf("😡 Expected the stored metadata to be unchanged, got %v", stored.Metadata)
+	}
+}
+
+// go test -v -run TestMemoryTaskStoreEviction
+func TestMemoryTaskStoreEviction(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTaskStore(MemoryTaskStoreConfig{TTL: 50 * time.Millisecond, MaxTasks: 2})
+	store.Save(ctx, Result{ID: "working", Status: TaskStatus{State: TaskStateWorking}})
+	store.Save(ctx, Result{ID: "first", Status: TaskStatus{State: TaskStateCompleted}})
+	store.Save(ctx, Result{ID: "second", Status: TaskStatus{State: TaskStateFailed}})
+
+	// The store is full: the oldest task done is evicted, not the working task
+	if _, err := store.Get(ctx, "first"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("😡 Expected the oldest task to be evicted, got %v", err)
+	}
+	if _, err := store.Get(ctx, "second"); err != nil {
+		t.Errorf("😡 Expected the last task to be kept: %v", err)
+	}
+
+	// The tasks done expire after the TTL
+	time.Sleep(60 * time.Millisecond)
+	if _, err := store.Get(ctx, "second"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("😡 Expected the task to expire, got %v", err)
+	}
+	if _, err := store.Get(ctx, "working"); err != nil {
+		t.Errorf("😡 Expected the working task to be kept: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package agents
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// AgentServer is a running HTTP server of the agent (REST API, MCP or A2A server).
+// It is returned by the Start*ServerAsync methods.
+type AgentServer struct {
+	server   *http.Server
+	listener net.Listener
+	// onForcedShutdown stops the in-flight work when the graceful shutdown times out
+	onForcedShutdown func()
+	// background tracks the work started by the requests that outlives them (e.g. the non-blocking A2A tasks)
+	background *sync.WaitGroup
+
+	done chan struct{}
+	err  error
+}
+
+// Addr returns the address the server listens on (e.g. "[::]:8080").
+// It is useful with the port "0" (random free port).
+func (agentServer *AgentServer) Addr() string {
+	return agentServer.listener.Addr().String()
+}
+
+// Shutdown stops the server gracefully: it stops accepting new requests and waits for the in-flight requests
+// and for the work they started in the background (e.g. the non-blocking A2A tasks).
+// When ctx expires before the end of the in-flight work, the running completions (and tasks) are cancelled
+// and the connections are closed.
+func (agentServer *AgentServer) Shutdown(ctx context.Context) error {
+	err := agentServer.server.Shutdown(ctx)
+	if err == nil {
+		err = agentServer.waitBackground(ctx)
+	}
+	if err != nil {
+		if agentServer.onForcedShutdown != nil {
+			agentServer.onForcedShutdown()
+		}
+		agentServer.server.Close()
+	}
+	<-agentServer.done
+	return err
+}
+
+// waitBackground waits for the background work of the requests, until ctx expires.
+func (agentServer *AgentServer) waitBackground(ctx context.Context) error {
+	if agentServer.background == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		agentServer.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait blocks until the server stops. It returns nil when the server was shut down.
+func (agentServer *AgentServer) Wait() error {
+	<-agentServer.done
+	return agentServer.err
+}
+
+// agentServers keeps the running servers of the agent, to stop them with Agent.Close.
+type agentServers struct {
+	mutex   sync.Mutex
+	servers []*AgentServer
+}
+
+// startServer starts a server with the security of the agent (ServerSecurityConfig) in the background.
+// background (optional) tracks the work started by the requests, Shutdown waits for it.
+// The TLS key pair (TLSCertFile, TLSKeyFile) is loaded before the server starts: a missing or invalid file is an error.
+func (agent *Agent) startServer(addr string, handler http.Handler, onForcedShutdown func(), background *sync.WaitGroup) (*AgentServer, error) {
+	var tlsConfig *tls.Config
+	if agent.serverSecurity.TLSCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(agent.serverSecurity.TLSCertFile, agent.serverSecurity.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	agentServer := &AgentServer{
+		server:           &http.Server{Handler: handler, TLSConfig: tlsConfig},
+		listener:         listener,
+		onForcedShutdown: onForcedShutdown,
+		background:       background,
+		done:             make(chan struct{}),
+	}
+
+	go func() {
+		defer close(agentServer.done)
+		var err error
+		if tlsConfig != nil {
+			// NOTE: the certificate is in the TLS configuration of the server
+			err = agentServer.server.ServeTLS(listener, "", "")
+		} else {
+			err = agentServer.server.Serve(listener)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			agentServer.err = err
+		}
+	}()
+
+	agent.servers.mutex.Lock()
+	agent.servers.servers = append(agent.servers.servers, agentServer)
+	agent.servers.mutex.Unlock()
+
+	return agentServer, nil
+}
+
+// Close stops the agent: it shuts down the servers started by the agent (see AgentServer.Shutdown),
+// cancels the running completions of the REST API server and the running A2A tasks, and closes the MCP clients
+// (the MCP STDIO client stops its server subprocess).
+func (agent *Agent) Close(ctx context.Context) error {
+	var errs []error
+
+	agent.servers.mutex.Lock()
+	servers := agent.servers.servers
+	agent.servers.servers = nil
+	agent.servers.mutex.Unlock()
+
+	for _, agentServer := range servers {
+		if err := agentServer.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if agent.httpCompletions != nil {
+		agent.httpCompletions.cancelAll()
+	}
+	if agent.a2aTasks != nil {
+		agent.a2aTasks.running.cancelAll()
+	}
+
+	if err := agent.closeMCPClients(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,20 +1,29 @@
 package agents
 
 import (
+	"context"
+	"maps"
 	"net/http"
+	"slices"
 
 	"github.com/budgies-nest/budgie/rag"
-	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/openai/openai-go"
 )
 
 type Agent struct {
-	clientEngine    openai.Client
 	Name            string
 	Params          openai.ChatCompletionNewParams
 	EmbeddingParams openai.EmbeddingNewParams
 
+	// Providers of the chat completions and of the embeddings (default: the OpenAI client, see WithDMR)
+	chatProvider      ChatProvider
+	embeddingProvider EmbeddingProvider
+	// Providers set with WithChatProvider and WithEmbeddingProvider, applied after all the options
+	// (so an endpoint option like WithDMR does not replace them, whatever the order of the options)
+	explicitChatProvider      ChatProvider
+	explicitEmbeddingProvider EmbeddingProvider
+
 	//Store           rag.MemoryVectorStore
 	Store         rag.VectorStore
 	storeFilePath string
@@ -24,17 +33,32 @@ type Agent struct {
 
 	httpServerConfig HTTPServerConfig
 	httpServer       *http.ServeMux
+	httpSessions     *httpSessionStore
+	httpCompletions  *httpCompletions
+
+	// Security of the HTTP servers (REST API, MCP and A2A)
+	serverSecurity ServerSecurityConfig
+	// Running servers, stopped by Close
+	servers *agentServers
 
 	//ToolCalls []openai.ChatCompletionMessageToolCall
 	//Instructions openai.ChatCompletionMessageParamUnion
 
 	Metadata map[string]any
 
+	// Implementations of the tools registered with RegisterTool
+	toolsRegistry map[string]func(any) (any, error)
+
 	optionError error
 
-	// MCP Clients
-	mpcStdioClient          *client.Client
-	mcpStreamableHTTPClient *client.Client
+	// MCP Clients by name (e.g. "stdio" or "http")
+	mcpClients map[string]*mcpConnection
+	// Configurations of the MCP clients by name (WithMCPClientConfig)
+	mcpClientConfigs map[string]MCPClientConfig
+	// Origin of the MCP tools: tool name (namespaced if needed) -> MCP client and tool name on the server
+	mcpToolsOrigin map[string]mcpToolOrigin
+	// Version of the tools of each MCP client in Params.Tools (see mcpConnection.toolsVersion)
+	mcpToolsVersions map[string]uint64
 
 	// Logger
 	logger *Logger
@@ -42,19 +66,46 @@ type Agent struct {
 	// Completion handlers
 	completionHandlers *CompletionHandlers
 
+	// Conversation memory strategy (nil: the messages are never trimmed)
+	conversationMemory ConversationMemory
+
+	// Policy of the tool calls (nil: all the tool calls are executed) and approver of the tool calls to confirm
+	toolPolicy   ToolPolicy
+	toolApprover ToolApprover
+
+	// Validation of the tool arguments against the JSON schema of the tools, and repair attempts by tool
+	toolValidation ToolValidationConfig
+	toolRepairs    *toolRepairAttempts
+
 	// --- A2A Server ---
 	// NOTE: This A2A protocol implementation is a subset of the A2A specification.
 	// IMPORTANT: This is a work in progress and may not cover all aspects of the A2A protocol.
 	a2aServerConfig A2AServerConfig
 	a2aServer       *http.ServeMux
 	agentCard       AgentCard
-	agentCallback   func(taskRequest TaskRequest) (TaskResponse, error)
+	agentCallback   func(ctx context.Context, taskRequest TaskRequest, streamContent func(content string) error) (TaskResponse, error)
+	a2aTasks        *a2aTasks
 }
 
 
 
 type AgentOption func(*Agent)
 
+// withMessages returns a shallow copy of the agent using its own list of messages.
+// The copy shares the client, the tool implementations, the handlers and the logger of the agent.
+// It gets its own copy of the tools, so it can refresh its MCP tools without changing the agent (and the other copies),
+// and its own count of the invalid tool calls (see ToolValidationConfig).
+// It is used to run isolated conversations (e.g. the HTTP sessions) with the same agent.
+func (agent *Agent) withMessages(messages []openai.ChatCompletionMessageParamUnion) *Agent {
+	clone := *agent
+	clone.Params.Messages = messages
+	clone.Params.Tools = slices.Clone(agent.Params.Tools)
+	clone.mcpToolsOrigin = maps.Clone(agent.mcpToolsOrigin)
+	clone.mcpToolsVersions = maps.Clone(agent.mcpToolsVersions)
+	clone.toolRepairs = newToolRepairAttempts()
+	return &clone
+}
+
 // NewAgent creates a new Agent instance with the provided options.
 // It applies all the options to the Agent and returns it.
 // If any option sets an error, it returns the error instead of the Agent.
@@ -65,11 +116,21 @@ func NewAgent(name string, options ...AgentOption) (*Agent, error) {
 	agent.Name = name
 	agent.logger = GetGlobalLogger()
 	agent.completionHandlers = NewCompletionHandlers()
+	agent.servers = &agentServers{}
+	agent.useProvider(&OpenAIProvider{})
+	agent.toolRepairs = newToolRepairAttempts()
 	// Apply all options
 	for _, option := range options {
 		option(agent)
 	}
+	if agent.explicitChatProvider != nil {
+		agent.chatProvider = agent.explicitChatProvider
+	}
+	if agent.explicitEmbeddingProvider != nil {
+		agent.embeddingProvider = agent.explicitEmbeddingProvider
+	}
 	if agent.optionError != nil {
+		agent.closeMCPClients() // NOTE: the agent is not returned, its MCP clients would never be closed
 		return nil, agent.optionError
 	}
 	return agent, nil
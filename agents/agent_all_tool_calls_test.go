@@ -5,13 +5,20 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/budgies-nest/budgie/enums/base"
+	"github.com/budgies-nest/budgie/agents/agentstest"
 	"github.com/budgies-nest/budgie/helpers"
 	"github.com/openai/openai-go"
 )
 
 // go test -v -run TestAllToolCalls
 func TestAllToolCalls(t *testing.T) {
+	// Scripted model: the model detects the 4 tool calls in a single request
+	model := agentstest.NewServer(t, agentstest.ToolCalls(
+		agentstest.Call("add", `{"a":10,"b":32}`),
+		agentstest.Call("add", `{"a":12,"b":30}`),
+		agentstest.Call("add", `{"a":40,"b":2}`),
+		agentstest.Call("add", `{"a":5,"b":37}`),
+	))
 
 	addTool := openai.ChatCompletionToolParam{
 		Function: openai.FunctionDefinitionParam{
@@ -35,7 +42,7 @@ func TestAllToolCalls(t *testing.T) {
 	}
 
 	bob, err := NewAgent("Bob",
-		WithDMR(base.DockerModelRunnerContainerURL),
+		WithDMR(model.URL),
 		WithParams(
 			openai.ChatCompletionNewParams{
 				Model:       "k33g/llama-xlam-2:8b-fc-r-q2_k", // NOTE: this model is able to detect several tool calls in a single request
@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/budgies-nest/budgie/enums/base"
+	"github.com/budgies-nest/budgie/agents/agentstest"
 	"github.com/budgies-nest/budgie/helpers"
 	"github.com/openai/openai-go"
 )
 
 // go test -v -run TestAlternativeAllToolCalls
 func TestAlternativeAllToolCalls(t *testing.T) {
+	// Scripted model: the model answers with the JSON array of the 4 tool calls
+	model := agentstest.NewServer(t, agentstest.Text(`{"function_calls":[{"name":"add","arguments":{"a":10,"b":32}},{"name":"add","arguments":{"a":12,"b":30}},{"name":"add","arguments":{"a":40,"b":2}},{"name":"add","arguments":{"a":5,"b":37}}]}`))
 
 	addTool := openai.ChatCompletionToolParam{
 		Function: openai.FunctionDefinitionParam{
@@ -35,7 +37,7 @@ func TestAlternativeAllToolCalls(t *testing.T) {
 	}
 
 	bob, err := NewAgent("Bob",
-		WithDMR(base.DockerModelRunnerContainerURL),
+		WithDMR(model.URL),
 		WithParams(
 			openai.ChatCompletionNewParams{
 				Model:       "ai/qwen2.5:latest",
@@ -0,0 +1,243 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+func conversationMessages() []openai.ChatCompletionMessageParamUnion {
+	return []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are Bob"),
+		openai.UserMessage("Hello"),
+		openai.AssistantMessage("Hi!"),
+		openai.UserMessage("Who is James Kirk?"),
+		openai.AssistantMessage("The captain of the Enterprise."),
+		openai.UserMessage("Who is his friend?"),
+	}
+}
+
+// go test -v -run TestWindowMemory
+func TestWindowMemory(t *testing.T) {
+	server := agentstest.NewServer(t, agentstest.Text("Spock"))
+
+	memory, err := NewWindowMemory(3)
+	if err != nil {
+		t.Fatalf("😡 Failed to create memory: %v", err)
+	}
+	bob, err := NewAgent("Bob",
+		WithDMR(server.URL),
+		WithModel("test"),
+		WithConversationMemory(memory),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.Params.Messages = conversationMessages()
+
+	if _, err := bob.ChatCompletion(context.Background()); err != nil {
+		t.Fatalf("😡 Failed to get chat completion: %v", err)
+	}
+
+	messages := server.ChatRequests()[0].Messages
+	if len(messages) != 4 {
+		t.Fatalf("😡 Expected 4 messages, got %d", len(messages))
+	}
+	if messages[0].Content != "You are Bob" {
+		t.Errorf("😡 Expected the system message to be pinned, got %+v", messages[0])
+	}
+	if messages[1].Content != "Who is James Kirk?" {
+		t.Errorf("😡 Unexpected first message of the window: %+v", messages[1])
+	}
+}
+
+// go test -v -run TestWindowMemoryDropsOrphanToolMessages
+func TestWindowMemoryDropsOrphanToolMessages(t *testing.T) {
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are Bob"),
+		openai.UserMessage("Add 40 and 2"),
+		openai.AssistantMessage(""),
+		openai.ToolMessage("42", "call_1"),
+		openai.AssistantMessage("42"),
+		openai.UserMessage("Thanks"),
+	}
+	trimmed, err := (&WindowMemory{MaxMessages: 3}).Trim(context.Background(), nil, messages)
+	if err != nil {
+		t.Fatalf("😡 Failed to trim: %v", err)
+	}
+	if len(trimmed) != 3 || trimmed[1].OfAssistant == nil {
+		t.Errorf("😡 Expected the orphan tool message to be dropped, got %d messages", len(trimmed))
+	}
+}
+
+// go test -v -run TestTokenBudgetMemory
+func TestTokenBudgetMemory(t *testing.T) {
+	messages := conversationMessages()
+	budget := EstimateTokens(messages[0]) + EstimateTokens(messages[4]) + EstimateTokens(messages[5])
+
+	trimmed, err := (&TokenBudgetMemory{MaxTokens: budget}).Trim(context.Background(), nil, messages)
+	if err != nil {
+		t.Fatalf("😡 Failed to trim: %v", err)
+	}
+	if len(trimmed) != 3 {
+		t.Fatalf("😡 Expected 3 messages, got %d", len(trimmed))
+	}
+	if messageContent(trimmed[1]) != "The captain of the Enterprise." {
+		t.Errorf("😡 Unexpected message: %s", messageContent(trimmed[1]))
+	}
+}
+
+// go test -v -run TestSummaryMemory
+func TestSummaryMemory(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.Text("The user asked about James Kirk."),
+		agentstest.Text("Spock"),
+	)
+
+	memory, err := NewSummaryMemory(4, 1)
+	if err != nil {
+		t.Fatalf("😡 Failed to create memory: %v", err)
+	}
+	bob, err := NewAgent("Bob",
+		WithDMR(server.URL),
+		WithModel("test"),
+		WithConversationMemory(memory),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.Params.Messages = conversationMessages()
+
+	answer, err := bob.ChatCompletion(context.Background())
+	if err != nil || answer != "Spock" {
+		t.Fatalf("😡 Unexpected chat completion: %s, %v", answer, err)
+	}
+
+	// The summary request contains the transcript of the oldest messages
+	transcript := server.ChatRequests()[0].Messages[1].Content
+	if !strings.Contains(transcript, "user: Who is James Kirk?") || strings.Contains(transcript, "Who is his friend?") {
+		t.Errorf("😡 Unexpected transcript: %s", transcript)
+	}
+
+	// system + summary + last user message
+	if len(bob.Params.Messages) != 3 {
+		t.Fatalf("😡 Expected 3 messages, got %d", len(bob.Params.Messages))
+	}
+	if !strings.HasSuffix(messageContent(bob.Params.Messages[1]), "The user asked about James Kirk.") {
+		t.Errorf("😡 Expected the summary, got %s", messageContent(bob.Params.Messages[1]))
+	}
+}
+
+// go test -v -run TestConversationMemoryInvalidSizes
+func TestConversationMemoryInvalidSizes(t *testing.T) {
+	if _, err := NewWindowMemory(0); !errors.Is(err, ErrInvalidConversationMemory) {
+		t.Errorf("😡 Expected an invalid window, got %v", err)
+	}
+	if _, err := NewTokenBudgetMemory(-1); !errors.Is(err, ErrInvalidConversationMemory) {
+		t.Errorf("😡 Expected an invalid budget, got %v", err)
+	}
+	if _, err := NewSummaryMemory(4, 4); !errors.Is(err, ErrInvalidConversationMemory) {
+		t.Errorf("😡 Expected an invalid summary memory, got %v", err)
+	}
+	if _, err := (&WindowMemory{}).Trim(context.Background(), nil, conversationMessages()); !errors.Is(err, ErrInvalidConversationMemory) {
+		t.Errorf("😡 Expected the zero window to be rejected, got %v", err)
+	}
+}
+
+// go test -v -run TestConversationMemoryKeepsTheLastUserTurn
+func TestConversationMemoryKeepsTheLastUserTurn(t *testing.T) {
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are Bob"),
+		openai.UserMessage("Hello"),
+		openai.SystemMessage("Answer in French"),
+		openai.AssistantMessage("Bonjour!"),
+		openai.UserMessage("Add 40 and 2, then multiply by " + strings.Repeat("1", 200)),
+		openai.AssistantMessage(""),
+		openai.ToolMessage("42", "call_1"),
+	}
+
+	trimmed, err := (&WindowMemory{MaxMessages: 1}).Trim(context.Background(), nil, messages)
+	if err != nil {
+		t.Fatalf("😡 Failed to trim: %v", err)
+	}
+	// system messages in place + the last user turn (user, assistant, tool)
+	contents := []string{}
+	for _, message := range trimmed {
+		contents = append(contents, messageRole(message))
+	}
+	if strings.Join(contents, ",") != "system,system,user,assistant,tool" {
+		t.Errorf("😡 Unexpected window: %v", contents)
+	}
+	if messageContent(trimmed[1]) != "Answer in French" {
+		t.Errorf("😡 Expected the order of the system messages to be kept, got %s", messageContent(trimmed[1]))
+	}
+
+	trimmed, err = (&TokenBudgetMemory{MaxTokens: 10}).Trim(context.Background(), nil, messages)
+	if err != nil {
+		t.Fatalf("😡 Failed to trim: %v", err)
+	}
+	if len(trimmed) != 5 || trimmed[2].OfUser == nil {
+		t.Errorf("😡 Expected the last user turn to be kept beyond the budget, got %d messages", len(trimmed))
+	}
+}
+
+// go test -v -run TestRunAppliesConversationMemoryOnce
+func TestRunAppliesConversationMemoryOnce(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("ping", `{}`)),
+		agentstest.ToolCalls(agentstest.Call("ping", `{}`)),
+		agentstest.Text("done"),
+	)
+
+	calls := 0
+	bob, err := NewAgent("Bob",
+		WithDMR(server.URL),
+		WithModel("test"),
+		WithTools([]openai.ChatCompletionToolParam{{
+			Function: openai.FunctionDefinitionParam{Name: "ping"},
+		}}),
+		WithConversationMemory(countingMemory{calls: &calls, memory: &WindowMemory{MaxMessages: 1}}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.Params.Messages = conversationMessages()
+
+	memoryDuringTheRun := true
+	_, err = bob.Run(context.Background(), "ping", RunOptions{
+		ToolsImpl: map[string]func(any) (any, error){
+			"ping": func(args any) (any, error) {
+				memoryDuringTheRun = memoryDuringTheRun && bob.conversationMemory != nil
+				return "pong", nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("😡 Expected the memory to be applied once, got %d", calls)
+	}
+	// system + user + 2 x (assistant + tool) + final answer
+	if len(bob.Params.Messages) != 7 {
+		t.Errorf("😡 Expected the messages of the turn to be kept, got %d", len(bob.Params.Messages))
+	}
+	// NOTE: the memory of the agent is never removed (the agent can be used concurrently)
+	if !memoryDuringTheRun || bob.conversationMemory == nil {
+		t.Errorf("😡 Expected the memory of the agent to be kept during the Run")
+	}
+}
+
+type countingMemory struct {
+	calls  *int
+	memory ConversationMemory
+}
+
+func (memory countingMemory) Trim(ctx context.Context, agent *Agent, messages []openai.ChatCompletionMessageParamUnion) ([]openai.ChatCompletionMessageParamUnion, error) {
+	*memory.calls++
+	return memory.memory.Trim(ctx, agent, messages)
+}
@@ -0,0 +1,80 @@
+package agents
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+// go test -v -run TestMCPAgentTool
+func TestMCPAgentTool(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("ship_of", `{"captain": "Kirk"}`)),
+		agentstest.Text("Kirk commands the Enterprise"),
+	)
+
+	type shipArgs struct {
+		Captain string `json:"captain"`
+	}
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithParams(openai.ChatCompletionNewParams{
+			Model: "test",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage("You are a Star Trek expert"),
+			},
+		}),
+		RegisterTool("ship_of", "Find the ship of a captain", func(args shipArgs) (string, error) {
+			return "Enterprise", nil
+		}),
+		WithMCPStreamableHttpServer(MCPServerConfig{Name: "bob", Version: "v1"}),
+		WithMCPAgentTool("ask_bob", "Ask Bob a question about Star Trek"),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.MCPHttpHandler())
+	defer server.Close()
+
+	sam, err := NewAgent("Sam",
+		WithMCPStreamableHttpClient(context.Background(), server.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPStreamableHttpTools(context.Background(), nil),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP client agent: %v", err)
+	}
+	defer sam.Close(context.Background())
+
+	if len(sam.Params.Tools) != 1 || sam.Params.Tools[0].Function.Name != "ask_bob" {
+		t.Fatalf("😡 Expected the ask_bob tool, got %+v", sam.Params.Tools)
+	}
+
+	results, err := sam.ExecuteMCPStreamableHTTPToolCalls(context.Background(), []openai.ChatCompletionMessageToolCall{{
+		ID: "call_1",
+		Function: openai.ChatCompletionMessageToolCallFunction{
+			Name:      "ask_bob",
+			Arguments: `{"prompt": "Which ship does Kirk command?"}`,
+		},
+	}})
+	if err != nil || len(results) != 1 || results[0] != "Kirk commands the Enterprise" {
+		t.Fatalf("😡 Unexpected results: %v %v", err, results)
+	}
+
+	// Bob ran its own tool loop, from its own messages
+	requests := model.ChatRequests()
+	if len(requests) != 2 || !strings.Contains(requests[1].LastMessage().Content, "Enterprise") {
+		t.Errorf("😡 Unexpected model requests: %+v", requests)
+	}
+	if len(bob.Params.Messages) != 1 {
+		t.Errorf("😡 Expected the agent's messages to be unchanged, got %d", len(bob.Params.Messages))
+	}
+
+	// The MCP server must be configured first
+	if _, err := NewAgent("Bob", WithMCPAgentTool("ask_bob", "Ask Bob")); err == nil {
+		t.Errorf("😡 Expected an error without MCP server")
+	}
+}
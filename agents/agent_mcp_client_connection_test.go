@@ -0,0 +1,213 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/openai/openai-go"
+)
+
+// go test -v -run TestMCPClientReconnection
+func TestMCPClientReconnection(t *testing.T) {
+	bob, err := NewAgent("Bob", WithMCPStreamableHttpServer(MCPServerConfig{Name: "bob", Version: "v1"}))
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.AddToolToMCPServer(mcp.NewTool("ping", mcp.WithDescription("Ping")),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("pong"), nil
+		},
+	)
+
+	// The server is "down" while down is set, the initialize requests are counted
+	var down atomic.Bool
+	var initializations atomic.Int32
+	mcpHandler := bob.MCPHttpHandler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte(`"initialize"`)) {
+			initializations.Add(1)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		mcpHandler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	sam, err := NewAgent("Sam",
+		WithMCPClientConfig("http", MCPClientConfig{
+			CallTimeout:          time.Second,
+			MaxReconnectAttempts: 5,
+			ReconnectBackoff:     10 * time.Millisecond,
+		}),
+		WithMCPStreamableHttpClient(ctx, server.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPStreamableHttpTools(ctx, nil),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP client agent: %v", err)
+	}
+	defer sam.Close(ctx)
+
+	// The server restarts during the tool call
+	down.Store(true)
+	time.AfterFunc(30*time.Millisecond, func() { down.Store(false) })
+
+	// The client reconnects, but the tool call is not retried (the tool may have been executed)
+	toolCalls := []openai.ChatCompletionMessageToolCall{
+		{ID: "call_1", Function: openai.ChatCompletionMessageToolCallFunction{Name: "ping", Arguments: `{}`}},
+	}
+	responses, err := sam.ExecuteToolCallsContext(ctx, toolCalls, nil)
+	if err != nil || len(responses) != 1 || !strings.Contains(responses[0], "not retried") {
+		t.Fatalf("😡 Expected the tool call to fail without retry: %v %v", err, responses)
+	}
+	responses, err = sam.ExecuteToolCallsContext(ctx, toolCalls, nil)
+	if err != nil || len(responses) != 1 || responses[0] != "pong" {
+		t.Fatalf("😡 Expected the next tool call to use the new session: %v %v", err, responses)
+	}
+	if initializations.Load() != 2 {
+		t.Errorf("😡 Expected a new session, got %d initializations", initializations.Load())
+	}
+
+	// While the server is down, every tool call reports the failed reconnection
+	down.Store(true)
+	for range 2 {
+		responses, err = sam.ExecuteToolCallsContext(ctx, toolCalls, nil)
+		if err != nil || len(responses) != 1 || !strings.Contains(responses[0], "reconnection failed") {
+			t.Fatalf("😡 Expected the reconnection error: %v %v", err, responses)
+		}
+	}
+	down.Store(false)
+	responses, err = sam.ExecuteToolCallsContext(ctx, toolCalls, nil)
+	if err != nil || len(responses) != 1 || responses[0] != "pong" {
+		t.Fatalf("😡 Expected the client to reconnect once the server is up: %v %v", err, responses)
+	}
+
+	// The configuration must be applied before the client
+	if _, err := NewAgent("Sam",
+		WithMCPStreamableHttpClient(ctx, server.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPClientConfig("http", MCPClientConfig{}),
+	); err == nil {
+		t.Errorf("😡 Expected an error for a configuration applied after the client")
+	}
+}
+
+// go test -v -run TestMCPToolsListChanged
+func TestMCPToolsListChanged(t *testing.T) {
+	bob, err := NewAgent("Bob", WithMCPStreamableHttpServer(MCPServerConfig{Name: "bob", Version: "v1"}))
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	bob.AddToolToMCPServer(mcp.NewTool("first", mcp.WithDescription("First")), handler)
+	server := httptest.NewServer(bob.MCPHttpHandler())
+	defer server.Close()
+
+	ctx := context.Background()
+	sam, err := NewAgent("Sam",
+		WithMCPClientConfig("http", MCPClientConfig{ListenForNotifications: true}),
+		WithMCPStreamableHttpClient(ctx, server.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPStreamableHttpTools(ctx, nil),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP client agent: %v", err)
+	}
+	defer sam.Close(ctx)
+
+	// NOTE: the tool is added again until the listening connection receives the notification
+	deadline := time.Now().Add(3 * time.Second)
+	for sam.mcpClients["http"].toolsVersion.Load() == sam.mcpToolsVersions["http"] && time.Now().Before(deadline) {
+		bob.AddToolToMCPServer(mcp.NewTool("second", mcp.WithDescription("Second")), handler)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	sam.refreshChangedMCPTools(ctx)
+	if !sam.hasTool("first") || !sam.hasTool("second") || len(sam.Params.Tools) != 2 {
+		t.Errorf("😡 Expected the tools to be refreshed, got %+v", sam.Params.Tools)
+	}
+}
+
+// go test -race -v -run TestMCPToolsListChangedConcurrentSessions
+func TestMCPToolsListChangedConcurrentSessions(t *testing.T) {
+	bob, err := NewAgent("Bob", WithMCPStreamableHttpServer(MCPServerConfig{Name: "bob", Version: "v1"}))
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	bob.AddToolToMCPServer(mcp.NewTool("first", mcp.WithDescription("First")), handler)
+	mcpServer := httptest.NewServer(bob.MCPHttpHandler())
+	defer mcpServer.Close()
+
+	const sessions = 8
+	model := agentstest.NewServer(t)
+	model.SetFallback(agentstest.Text("ok"))
+
+	ctx := context.Background()
+	sam, err := NewAgent("Sam",
+		WithDMR(model.URL),
+		WithModel("test"),
+		WithHTTPServer(HTTPServerConfig{}),
+		WithMCPClientConfig("http", MCPClientConfig{ListenForNotifications: true}),
+		WithMCPStreamableHttpClient(ctx, mcpServer.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPStreamableHttpTools(ctx, nil),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP client agent: %v", err)
+	}
+	defer sam.Close(ctx)
+	server := httptest.NewServer(sam.HttpServer())
+	defer server.Close()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for sam.mcpClients["http"].toolsVersion.Load() == sam.mcpToolsVersions["http"] && time.Now().Before(deadline) {
+		bob.AddToolToMCPServer(mcp.NewTool("second", mcp.WithDescription("Second")), handler)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Each session refreshes its own copy of the tools, concurrently
+	var wg sync.WaitGroup
+	for range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := http.Post(server.URL+"/api/chat", "application/json", strings.NewReader(`{"user": "Hello"}`))
+			if err != nil {
+				t.Errorf("😡 Failed to call the chat endpoint: %v", err)
+				return
+			}
+			response.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	for i, request := range model.ChatRequests() {
+		if len(request.Tools) != 2 {
+			t.Errorf("😡 Expected the refreshed tools in the request %d, got %v", i, request.Tools)
+		}
+	}
+	// The agent itself is not changed by its sessions
+	if len(sam.Params.Tools) != 1 {
+		t.Errorf("😡 Expected the tools of the agent to be unchanged, got %+v", sam.Params.Tools)
+	}
+	sam.refreshChangedMCPTools(ctx)
+	if len(sam.Params.Tools) != 2 {
+		t.Errorf("😡 Expected the tools of the agent to be refreshed, got %+v", sam.Params.Tools)
+	}
+}
@@ -0,0 +1,92 @@
+package agents
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/openai/openai-go"
+)
+
+// newMCPSearchServer starts an MCP server with a "search" tool answering with the given prefix.
+func newMCPSearchServer(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	agent, err := NewAgent(name, WithMCPStreamableHttpServer(MCPServerConfig{Name: name, Version: "v1"}))
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP server agent: %v", err)
+	}
+	agent.AddToolToMCPServer(
+		mcp.NewTool("search", mcp.WithDescription("Search"), mcp.WithString("query", mcp.Required())),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(name + ": " + request.GetString("query", "")), nil
+		},
+	)
+	server := httptest.NewServer(agent.MCPHttpHandler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// go test -v -run TestMultipleMCPClients
+func TestMultipleMCPClients(t *testing.T) {
+	docs := newMCPSearchServer(t, "docs")
+	web := newMCPSearchServer(t, "web")
+
+	type pingArgs struct{}
+	ctx := context.Background()
+	bob, err := NewAgent("Bob",
+		RegisterTool("ping", "Ping", func(args pingArgs) (string, error) { return "pong", nil }),
+		WithNamedMCPStreamableHttpClient(ctx, "docs", docs.URL+"/mcp", StreamableHttpOptions{}),
+		WithNamedMCPStreamableHttpClient(ctx, "web", web.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPClientTools(ctx, "docs", nil),
+		WithMCPClientTools(ctx, "web", nil),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	defer bob.Close(ctx)
+
+	if names := bob.MCPClientNames(); !slices.Equal(names, []string{"docs", "web"}) {
+		t.Errorf("😡 Unexpected MCP clients: %v", names)
+	}
+	toolNames := []string{}
+	for _, tool := range bob.Params.Tools {
+		toolNames = append(toolNames, tool.Function.Name)
+	}
+	if !slices.Equal(toolNames, []string{"ping", "search", "web__search"}) {
+		t.Fatalf("😡 Expected the colliding tool to be namespaced, got %v", toolNames)
+	}
+
+	responses, err := bob.ExecuteToolCalls([]openai.ChatCompletionMessageToolCall{
+		{ID: "call_1", Function: openai.ChatCompletionMessageToolCallFunction{Name: "ping", Arguments: `{}`}},
+		{ID: "call_2", Function: openai.ChatCompletionMessageToolCallFunction{Name: "search", Arguments: `{"query": "warp"}`}},
+		{ID: "call_3", Function: openai.ChatCompletionMessageToolCallFunction{Name: "web__search", Arguments: `{"query": "warp"}`}},
+		{ID: "call_4", Function: openai.ChatCompletionMessageToolCallFunction{Name: "unknown", Arguments: `{}`}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("😡 Failed to execute the tool calls: %v", err)
+	}
+	if !slices.Equal(responses, []string{"pong", "docs: warp", "web: warp", "error: tool not implemented: unknown"}) {
+		t.Errorf("😡 Unexpected responses: %v", responses)
+	}
+	if len(bob.Params.Messages) != 4 {
+		t.Errorf("😡 Expected 4 tool messages, got %d", len(bob.Params.Messages))
+	}
+
+	// The client specific dispatcher translates the namespaced names
+	responses, err = bob.ExecuteMCPToolCalls(ctx, "web", []openai.ChatCompletionMessageToolCall{
+		{ID: "call_5", Function: openai.ChatCompletionMessageToolCallFunction{Name: "web__search", Arguments: `{"query": "impulse"}`}},
+	})
+	if err != nil || !slices.Equal(responses, []string{"web: impulse"}) {
+		t.Errorf("😡 Unexpected responses: %v %v", err, responses)
+	}
+
+	// The client names are unique
+	if _, err := NewAgent("Sam",
+		WithNamedMCPStreamableHttpClient(ctx, "docs", docs.URL+"/mcp", StreamableHttpOptions{}),
+		WithNamedMCPStreamableHttpClient(ctx, "docs", web.URL+"/mcp", StreamableHttpOptions{}),
+	); err == nil {
+		t.Errorf("😡 Expected an error for a duplicate client name")
+	}
+}
@@ -0,0 +1,116 @@
+package agents
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/rag"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/openai/openai-go"
+)
+
+// go test -v -run TestMCPResourcesAndPrompts
+func TestMCPResourcesAndPrompts(t *testing.T) {
+	bob, err := NewAgent("Bob",
+		WithMCPStreamableHttpServer(MCPServerConfig{Name: "bob", Version: "v1"}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.Store = &rag.MemoryVectorStore{Records: map[string]rag.VectorRecord{
+		"kirk": {Id: "kirk", Prompt: "James T. Kirk is the captain of the Enterprise"},
+	}}
+	if err := bob.AddRAGDocumentsToMCPServer(""); err != nil {
+		t.Fatalf("😡 Failed to publish the RAG documents: %v", err)
+	}
+	bob.AddTextResourceToMCPServer("docs://rules", "rules", "The rules", "text/plain", "Always answer in English")
+	bob.AddPromptTemplateToMCPServer("captain", "Ask about a captain", "Who is {{name}}?", "name")
+
+	server := httptest.NewServer(bob.MCPHttpHandler())
+	defer server.Close()
+
+	ctx := context.Background()
+	sam, err := NewAgent("Sam",
+		WithParams(openai.ChatCompletionNewParams{Model: "test"}),
+		WithMCPStreamableHttpClient(ctx, server.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPResources(ctx, "http", "rag://Bob/kirk", "docs://rules"),
+		WithMCPPrompt(ctx, "http", "captain", map[string]string{"name": "Kirk"}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP client agent: %v", err)
+	}
+	defer sam.Close(ctx)
+
+	resources, err := sam.ListMCPResources(ctx, "http")
+	if err != nil || len(resources) != 2 {
+		t.Fatalf("😡 Expected 2 resources: %v %v", err, resources)
+	}
+	prompts, err := sam.ListMCPPrompts(ctx, "http")
+	if err != nil || len(prompts) != 1 || prompts[0].Name != "captain" {
+		t.Fatalf("😡 Expected the captain prompt: %v %v", err, prompts)
+	}
+
+	messages := sam.Params.Messages
+	if len(messages) != 3 {
+		t.Fatalf("😡 Expected 3 messages, got %d", len(messages))
+	}
+	if messages[0].OfSystem == nil || !strings.Contains(messages[0].OfSystem.Content.OfString.Value, "captain of the Enterprise") {
+		t.Errorf("😡 Expected the RAG document as context, got %+v", messages[0])
+	}
+	if messages[2].OfUser == nil || messages[2].OfUser.Content.OfString.Value != "Who is Kirk?" {
+		t.Errorf("😡 Expected the prompt as a user message, got %+v", messages[2])
+	}
+
+	// Errors
+	if _, err := sam.ReadMCPResource(ctx, "http", "docs://unknown"); err == nil {
+		t.Errorf("😡 Expected an error for an unknown resource")
+	}
+	if _, err := sam.GetMCPPrompt(ctx, "http", "captain", nil); err == nil {
+		t.Errorf("😡 Expected an error for a missing argument")
+	}
+	if _, err := sam.ListMCPResources(ctx, "stdio"); err == nil {
+		t.Errorf("😡 Expected an error without stdio client")
+	}
+}
+
+// go test -v -run TestMCPResourcesAndPromptsPagination
+func TestMCPResourcesAndPromptsPagination(t *testing.T) {
+	// One resource and one prompt per page
+	mcpServer := server.NewMCPServer("bob", "v1", server.WithPaginationLimit(1))
+	for _, name := range []string{"kirk", "picard", "janeway"} {
+		mcpServer.AddResource(mcp.NewResource("docs://"+name, name),
+			func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+				return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, Text: name}}, nil
+			},
+		)
+		mcpServer.AddPrompt(mcp.NewPrompt(name),
+			func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				return mcp.NewGetPromptResult(name, []mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(name))}), nil
+			},
+		)
+	}
+	httpServer := httptest.NewServer(server.NewStreamableHTTPServer(mcpServer))
+	defer httpServer.Close()
+
+	ctx := context.Background()
+	sam, err := NewAgent("Sam",
+		WithParams(openai.ChatCompletionNewParams{Model: "test"}),
+		WithMCPStreamableHttpClient(ctx, httpServer.URL+"/mcp", StreamableHttpOptions{}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP client agent: %v", err)
+	}
+	defer sam.Close(ctx)
+
+	resources, err := sam.ListMCPResources(ctx, "http")
+	if err != nil || len(resources) != 3 {
+		t.Fatalf("😡 Expected the 3 resources of all the pages: %v %v", err, resources)
+	}
+	prompts, err := sam.ListMCPPrompts(ctx, "http")
+	if err != nil || len(prompts) != 3 {
+		t.Fatalf("😡 Expected the 3 prompts of all the pages: %v %v", err, prompts)
+	}
+}
@@ -0,0 +1,117 @@
+package agents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// go test -v -run TestMCPStdioServer
+func TestMCPStdioServer(t *testing.T) {
+	bob, err := NewAgent("Bob",
+		WithMCPStdioServer(MCPServerConfig{Name: "bob", Version: "v1"}),
+		WithLogging(LogLevelInfo, false),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.AddToolToMCPServer(mcp.NewTool("ping", mcp.WithDescription("Ping")),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("pong"), nil
+		},
+	)
+	bob.AddPromptTemplateToMCPServer("captain", "Ask about a captain", "Who is {{name}}?", "name")
+
+	logger := bob.logger
+	stdoutReserved := func() bool {
+		logger.mutex.RLock()
+		defer logger.mutex.RUnlock()
+		return logger.stdioServers > 0
+	}
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	served := make(chan error, 1)
+	go func() {
+		served <- bob.ServeMCPStdio(context.Background(), stdinReader, stdoutWriter)
+		stdoutWriter.Close()
+	}()
+
+	responses := bufio.NewScanner(stdoutReader)
+	call := func(id int, method string, params any) map[string]any {
+		request, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+		if _, err := stdinWriter.Write(append(request, '\n')); err != nil {
+			t.Fatalf("😡 Failed to write the request: %v", err)
+		}
+		if !responses.Scan() {
+			t.Fatalf("😡 No response to %s", method)
+		}
+		var response map[string]any
+		if err := json.Unmarshal(responses.Bytes(), &response); err != nil {
+			t.Fatalf("😡 Invalid response to %s: %s", method, responses.Text())
+		}
+		return response
+	}
+
+	call(1, "initialize", map[string]any{
+		"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+		"clientInfo":      map[string]any{"name": "test", "version": "v1"},
+		"capabilities":    map[string]any{},
+	})
+
+	// The logs are written to stderr while the server is running
+	if !stdoutReserved() {
+		t.Errorf("😡 Expected stdout to be reserved for the MCP protocol")
+	}
+
+	tools := call(2, "tools/list", map[string]any{})
+	if !strings.Contains(responses.Text(), `"name":"ping"`) {
+		t.Errorf("😡 Expected the ping tool, got %v", tools)
+	}
+
+	result := call(3, "tools/call", map[string]any{"name": "ping", "arguments": map[string]any{}})
+	if !strings.Contains(responses.Text(), `"text":"pong"`) {
+		t.Errorf("😡 Unexpected tool result: %v", result)
+	}
+
+	prompt := call(4, "prompts/get", map[string]any{"name": "captain", "arguments": map[string]any{"name": "Kirk"}})
+	if !strings.Contains(responses.Text(), "Who is Kirk?") {
+		t.Errorf("😡 Unexpected prompt: %v", prompt)
+	}
+
+	// The server stops when stdin is closed
+	stdinWriter.Close()
+	if err := <-served; err != nil {
+		t.Errorf("😡 Unexpected error: %v", err)
+	}
+	if bob.logger != logger || stdoutReserved() {
+		t.Errorf("😡 Expected the logger of the agent to write to stdout again")
+	}
+
+	sam, err := NewAgent("Sam", WithLogging(LogLevelInfo, false))
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	if err := sam.ServeMCPStdio(context.Background(), strings.NewReader(""), io.Discard); err == nil {
+		t.Errorf("😡 Expected an error without MCP server")
+	}
+
+	// The MCP server is shared by the stdio and the HTTP servers: they must have the same name and version
+	if _, err := NewAgent("Sam",
+		WithMCPStreamableHttpServer(MCPServerConfig{Name: "sam", Version: "v1"}),
+		WithMCPStdioServer(MCPServerConfig{Name: "sam", Version: "v1"}),
+	); err != nil {
+		t.Errorf("😡 Expected the same MCP server for stdio and HTTP: %v", err)
+	}
+	if _, err := NewAgent("Sam",
+		WithMCPStdioServer(MCPServerConfig{Name: "sam", Version: "v1"}),
+		WithMCPStreamableHttpServer(MCPServerConfig{Name: "sam", Version: "v2"}),
+	); err == nil {
+		t.Errorf("😡 Expected an error for another version of the MCP server")
+	}
+}
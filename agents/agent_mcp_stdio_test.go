@@ -5,16 +5,18 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/budgies-nest/budgie/enums/base"
+	"github.com/budgies-nest/budgie/agents/agentstest"
 	"github.com/budgies-nest/budgie/helpers"
 	"github.com/openai/openai-go"
 )
 
 // go test -v -run TestMCPStdioOneToolCall
 func TestMCPStdioOneToolCall(t *testing.T) {
+	// Scripted model: the model detects the tool call, the tool is executed by the MCP stdio server
+	model := agentstest.NewServer(t, agentstest.ToolCalls(agentstest.Call("say_hello", `{"name":"Bob"}`)))
 
 	bob, err := NewAgent("Bob",
-		WithDMR(base.DockerModelRunnerContainerURL),
+		WithDMR(model.URL),
 		WithParams(
 			openai.ChatCompletionNewParams{
 				Model: "k33g/qwen2.5:0.5b-instruct-q8_0",
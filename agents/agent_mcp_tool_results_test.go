@@ -0,0 +1,65 @@
+package agents
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/openai/openai-go"
+)
+
+// go test -v -run TestMCPToolResults
+func TestMCPToolResults(t *testing.T) {
+	bob, err := NewAgent("Bob", WithMCPStreamableHttpServer(MCPServerConfig{Name: "bob", Version: "v1"}))
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.AddToolToMCPServer(mcp.NewTool("render", mcp.WithDescription("Render")),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewTextContent("Here is the chart"),
+				mcp.NewImageContent("aGVsbG8=", "image/png"),
+				mcp.NewEmbeddedResource(mcp.TextResourceContents{URI: "docs://data", MIMEType: "text/csv", Text: "a,b\n1,2"}),
+			}}, nil
+		},
+	)
+	bob.AddToolToMCPServer(mcp.NewTool("fail", mcp.WithDescription("Fail")),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultError("the database is down"), nil
+		},
+	)
+	server := httptest.NewServer(bob.MCPHttpHandler())
+	defer server.Close()
+
+	ctx := context.Background()
+	sam, err := NewAgent("Sam",
+		WithMCPStreamableHttpClient(ctx, server.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPStreamableHttpTools(ctx, nil),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create the MCP client agent: %v", err)
+	}
+	defer sam.Close(ctx)
+
+	responses, err := sam.ExecuteMCPStreamableHTTPToolCalls(ctx, []openai.ChatCompletionMessageToolCall{
+		{ID: "call_1", Function: openai.ChatCompletionMessageToolCallFunction{Name: "render", Arguments: `{}`}},
+		{ID: "call_2", Function: openai.ChatCompletionMessageToolCallFunction{Name: "fail", Arguments: `{}`}},
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to execute the tool calls: %v", err)
+	}
+	expected := []string{
+		"Here is the chart\n[image: image/png, 8 bytes (base64)]\na,b\n1,2",
+		"error: tool fail failed: the database is down",
+	}
+	if !slices.Equal(responses, expected) {
+		t.Errorf("😡 Unexpected responses: %q", responses)
+	}
+
+	// Each tool call gets a tool message, the errors included
+	if len(sam.Params.Messages) != 2 || sam.Params.Messages[1].OfTool.Content.OfString.Value != expected[1] {
+		t.Errorf("😡 Unexpected messages: %+v", sam.Params.Messages)
+	}
+}
@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/budgies-nest/budgie/enums/base"
+	"github.com/budgies-nest/budgie/agents/agentstest"
 	"github.com/budgies-nest/budgie/helpers"
 	"github.com/openai/openai-go"
 )
 
 // go test -v -run TestOneToolCall
 func TestOneToolCall(t *testing.T) {
+	// Scripted model: the model detects the tool call
+	model := agentstest.NewServer(t, agentstest.ToolCalls(agentstest.Call("add", `{"a":10,"b":32}`)))
 
 	addTool := openai.ChatCompletionToolParam{
 		Function: openai.FunctionDefinitionParam{
@@ -35,7 +37,7 @@ func TestOneToolCall(t *testing.T) {
 	}
 
 	bob, err := NewAgent("Bob",
-		WithDMR(base.DockerModelRunnerContainerURL),
+		WithDMR(model.URL),
 		WithParams(
 			openai.ChatCompletionNewParams{
 				Model:       "k33g/qwen2.5:0.5b-instruct-q8_0",
@@ -6,13 +6,16 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/budgies-nest/budgie/enums/base"
+	"github.com/budgies-nest/budgie/agents/agentstest"
 	"github.com/openai/openai-go"
 )
 
 // go test -v -run TestHawaiianPizzaExpert
 // Test the chat Completion for a Hawaiian Pizza Expert agent
 func TestHawaiianPizzaExpert(t *testing.T) {
+	model := agentstest.NewServer(t, agentstest.Text(
+		"The main ingredients of the Hawaiian pizza are tomato sauce, mozzarella cheese, ham (or Canadian bacon) and pineapple.",
+	))
 
 	messages := []openai.ChatCompletionMessageParamUnion{
 		openai.SystemMessage(`
@@ -46,7 +49,7 @@ func TestHawaiianPizzaExpert(t *testing.T) {
 	}
 
 	bob, err := NewAgent("Bob",
-		WithDMR(base.DockerModelRunnerContainerURL),
+		WithDMR(model.URL),
 		WithParams(openai.ChatCompletionNewParams{
 			Model:       "ai/qwen2.5:0.5B-F16",
 			Temperature: openai.Opt(0.8),
@@ -64,12 +67,8 @@ func TestHawaiianPizzaExpert(t *testing.T) {
 
 	fmt.Println("🐳🤖 Chat completion result:", response)
 
-	response = strings.ToLower(response)
-
-	expectedWords := []string{"cheese", "bacon", "pineapple"}
-	for _, word := range expectedWords {
-		if !strings.Contains(response, word) {
-			t.Errorf("😡 Expected response to contain word '%s', but it was not found", word)
-		}
+	// The knowledge base is sent to the model
+	if systemMessages := model.LastRequest().MessagesWithRole("system"); len(systemMessages) != 2 || !strings.Contains(systemMessages[1].Content, "KNOWLEDGE BASE") {
+		t.Errorf("😡 Expected the knowledge base in the system messages, got %+v", systemMessages)
 	}
 }
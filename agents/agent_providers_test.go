@@ -0,0 +1,207 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+// fakeProvider is a scripted ChatProvider and EmbeddingProvider, without HTTP server.
+type fakeProvider struct {
+	answers []string
+	params  []openai.ChatCompletionNewParams
+}
+
+func (provider *fakeProvider) next(params openai.ChatCompletionNewParams) (string, error) {
+	provider.params = append(provider.params, params)
+	if len(provider.answers) == 0 {
+		return "", errors.New("no more answers")
+	}
+	answer := provider.answers[0]
+	provider.answers = provider.answers[1:]
+	return answer, nil
+}
+
+func (provider *fakeProvider) ChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	answer, err := provider.next(params)
+	if err != nil {
+		return nil, err
+	}
+	return &openai.ChatCompletion{Choices: []openai.ChatCompletionChoice{{
+		FinishReason: "stop",
+		Message:      openai.ChatCompletionMessage{Role: "assistant", Content: answer},
+	}}}, nil
+}
+
+func (provider *fakeProvider) ChatCompletionStream(ctx context.Context, params openai.ChatCompletionNewParams, onChunk func(chunk openai.ChatCompletionChunk) error) error {
+	answer, err := provider.next(params)
+	if err != nil {
+		return err
+	}
+	for _, word := range strings.SplitAfter(answer, " ") {
+		chunk := openai.ChatCompletionChunk{Choices: []openai.ChatCompletionChunkChoice{{
+			Delta: openai.ChatCompletionChunkChoiceDelta{Content: word},
+		}}}
+		if err := onChunk(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (provider *fakeProvider) CreateEmbeddings(ctx context.Context, params openai.EmbeddingNewParams) (*openai.CreateEmbeddingResponse, error) {
+	return &openai.CreateEmbeddingResponse{Data: []openai.Embedding{{Embedding: []float64{float64(len(params.Input.OfString.Value)), 1}}}}, nil
+}
+
+// go test -v -run TestChatProvider
+func TestChatProvider(t *testing.T) {
+	provider := &fakeProvider{answers: []string{"Hello Bob", "Hello again Bob"}}
+	bob, err := NewAgent("Bob",
+		WithChatProvider(provider),
+		WithEmbeddingProvider(provider),
+		WithModel("fake"),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	ctx := context.Background()
+
+	bob.AddUserMessage("Hello")
+	answer, err := bob.ChatCompletion(ctx)
+	if err != nil || answer != "Hello Bob" {
+		t.Errorf("😡 Unexpected answer: %q %v", answer, err)
+	}
+	streamed := []string{}
+	answer, err = bob.ChatCompletionStream(ctx, func(self *Agent, content string, err error) error {
+		streamed = append(streamed, content)
+		return nil
+	})
+	if err != nil || answer != "Hello again Bob" || len(streamed) != 3 {
+		t.Errorf("😡 Unexpected streamed answer: %q %v %v", answer, streamed, err)
+	}
+	if len(provider.params) != 2 || provider.params[0].Model != "fake" {
+		t.Errorf("😡 Expected the parameters of the agent, got %+v", provider.params)
+	}
+
+	embedding, err := bob.CreateEmbeddingFromText(ctx, "budgie")
+	if err != nil || len(embedding.Embedding) != 2 || embedding.Embedding[0] != 6 {
+		t.Errorf("😡 Unexpected embedding: %+v %v", embedding, err)
+	}
+
+	// The explicit providers win over the endpoint options, whatever the order of the options
+	sam, err := NewAgent("Sam",
+		WithChatProvider(provider),
+		WithEmbeddingProvider(provider),
+		WithDMR("http://localhost:1"),
+		WithOllama("http://localhost:1"),
+	)
+	if err != nil || sam.chatProvider != provider || sam.embeddingProvider != provider {
+		t.Errorf("😡 Expected the explicit providers to be kept: %v", err)
+	}
+}
+
+// newFakeOllamaServer answers /api/chat with the tool call "add" (or with "The result is 42" once the tool result is sent),
+// and /api/embed with an embedding by input. It records the chat requests.
+func newFakeOllamaServer(t *testing.T, requests *[]map[string]any) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		switch r.URL.Path {
+		case "/api/embed":
+			w.Write([]byte(`{"model":"embeddinggemma","embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":4}`))
+		case "/api/chat":
+			*requests = append(*requests, request)
+			messages := request["messages"].([]any)
+			if messages[len(messages)-1].(map[string]any)["role"] == "tool" {
+				if request["stream"] == true {
+					w.Write([]byte(`{"model":"qwen3","message":{"role":"assistant","content":"The result"},"done":false}` + "\n"))
+					w.Write([]byte(`{"model":"qwen3","message":{"role":"assistant","content":" is 42"},"done":false}` + "\n"))
+					w.Write([]byte(`{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}` + "\n"))
+					return
+				}
+				w.Write([]byte(`{"model":"qwen3","message":{"role":"assistant","content":"The result is 42"},"done":true,"done_reason":"stop","prompt_eval_count":20,"eval_count":5}`))
+				return
+			}
+			w.Write([]byte(`{"model":"qwen3","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"add","arguments":{"a":10,"b":32}}}]},"done":true,"done_reason":"stop"}` + "\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model 'unknown' not found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// go test -v -run TestOllamaProvider
+func TestOllamaProvider(t *testing.T) {
+	requests := []map[string]any{}
+	ollama := newFakeOllamaServer(t, &requests)
+
+	for _, stream := range []bool{false, true} {
+		calls := 0
+		bob, err := NewAgent("Bob",
+			WithOllama(ollama.URL),
+			WithModel("test"),
+			RegisterTool("add", "add two numbers", func(args addArgs) (float64, error) {
+				calls++
+				return args.A + args.B, nil
+			}),
+		)
+		if err != nil {
+			t.Fatalf("😡 Failed to create agent: %v", err)
+		}
+		bob.Params.Temperature = openai.Float(0.2)
+		bob.Params.Messages = append(bob.Params.Messages, openai.DeveloperMessage("You are a calculator"))
+
+		var result RunResult
+		if stream {
+			result, err = bob.RunStream(context.Background(), "Add 10 and 32", RunOptions{}, func(self *Agent, content string, err error) error { return nil })
+		} else {
+			result, err = bob.Run(context.Background(), "Add 10 and 32", RunOptions{})
+		}
+		if err != nil {
+			t.Fatalf("😡 Failed to run (stream: %v): %v", stream, err)
+		}
+		if result.Answer != "The result is 42" || calls != 1 || result.Steps[0].Results[0] != "42" {
+			t.Errorf("😡 Unexpected result (stream: %v): %+v", stream, result)
+		}
+	}
+
+	// The second request of the loop sends the tool call and the tool result
+	request := requests[1]
+	if request["options"].(map[string]any)["temperature"] != 0.2 || request["tools"] == nil {
+		t.Errorf("😡 Expected the options and the tools: %v", request)
+	}
+	messages := request["messages"].([]any)
+	if messages[0].(map[string]any)["role"] != "system" {
+		t.Errorf("😡 Expected the developer message as a system message: %v", messages[0])
+	}
+	toolCall := messages[2].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)["function"].(map[string]any)
+	if toolCall["arguments"].(map[string]any)["b"] != 32.0 {
+		t.Errorf("😡 Expected the arguments as an object: %v", toolCall)
+	}
+	if tool := messages[3].(map[string]any); tool["tool_name"] != "add" || tool["content"] != "42" {
+		t.Errorf("😡 Unexpected tool message: %v", tool)
+	}
+
+	// Embeddings and errors
+	provider := NewOllamaProvider(ollama.URL + "/")
+	embeddings, err := provider.CreateEmbeddings(context.Background(), openai.EmbeddingNewParams{
+		Model: "embeddinggemma",
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: []string{"a", "b"}},
+	})
+	if err != nil || len(embeddings.Data) != 2 || embeddings.Data[1].Embedding[0] != 0.3 || embeddings.Data[1].Index != 1 {
+		t.Errorf("😡 Unexpected embeddings: %+v %v", embeddings, err)
+	}
+	provider = NewOllamaProvider(ollama.URL + "/unknown")
+	if _, err := provider.ChatCompletion(context.Background(), openai.ChatCompletionNewParams{Model: "unknown"}); err == nil || !strings.Contains(err.Error(), "model 'unknown' not found") {
+		t.Errorf("😡 Expected the error of Ollama, got %v", err)
+	}
+}
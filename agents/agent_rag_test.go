@@ -3,9 +3,10 @@ package agents
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
-	"github.com/budgies-nest/budgie/enums/base"
+	"github.com/budgies-nest/budgie/agents/agentstest"
 	"github.com/openai/openai-go"
 )
 
@@ -46,8 +47,22 @@ var chunks = []string{
 
 // go test -v -run TestRagMemory
 func TestRagMemory(t *testing.T) {
+	// The embeddings are the characters named in the text: only the chunk about Emma Peel is close to the question
+	model := agentstest.NewServer(t)
+	model.SetEmbedding(func(text string) []float64 {
+		embedding := []float64{}
+		for _, character := range []string{"Avengers", "John Steed", "Emma Peel", "Tara King", "Mother", "Steed"} {
+			if strings.Contains(text, character) {
+				embedding = append(embedding, 1)
+			} else {
+				embedding = append(embedding, 0)
+			}
+		}
+		return embedding
+	})
+
 	bob, err := NewAgent("Bob",
-		WithDMR(base.DockerModelRunnerContainerURL),
+		WithDMR(model.URL),
 		WithEmbeddingParams(
 			openai.EmbeddingNewParams{
 				Model: "ai/mxbai-embed-large",
@@ -67,8 +82,10 @@ func TestRagMemory(t *testing.T) {
 	for _, similarity := range similarities {
 		fmt.Println("-", similarity)
 	}
-	if len(similarities) == 0 {
-		t.Fatalf("😡 No similarities found, expected at least one match.")
+	if len(similarities) != 1 {
+		t.Fatalf("😡 Expected 1 similarity, got %d", len(similarities))
+	}
+	if similarities[0] != chunks[2] {
+		t.Errorf("😡 Expected the chunk about Emma Peel, got %s", similarities[0])
 	}
-	// TODO: test the content of the similarities
 }
@@ -0,0 +1,164 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+// go test -v -run TestRun
+func TestRun(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("add", `{"a":10,"b":32}`), agentstest.Call("unknown", `{}`)),
+		agentstest.Text("The result is 42"),
+	)
+
+	bob, err := NewAgent("Bob",
+		WithDMR(server.URL),
+		WithModel("test"),
+		WithTools([]openai.ChatCompletionToolParam{{
+			Function: openai.FunctionDefinitionParam{Name: "add"},
+		}}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	result, err := bob.Run(context.Background(), "Add 10 and 32", RunOptions{
+		ToolsImpl: map[string]func(any) (any, error){
+			"add": func(args any) (any, error) {
+				a := args.(map[string]any)["a"].(float64)
+				b := args.(map[string]any)["b"].(float64)
+				return a + b, nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if result.Answer != "The result is 42" {
+		t.Errorf("😡 Unexpected answer: %s", result.Answer)
+	}
+	if len(result.Steps) != 1 || len(result.Steps[0].Results) != 2 {
+		t.Fatalf("😡 Expected 1 step with 2 results, got %+v", result.Steps)
+	}
+	if result.Steps[0].Results[0] != "42" {
+		t.Errorf("😡 Expected 42, got %s", result.Steps[0].Results[0])
+	}
+	if !strings.Contains(result.Steps[0].Results[1], "not implemented") {
+		t.Errorf("😡 Expected a not implemented message, got %s", result.Steps[0].Results[1])
+	}
+
+	// user + assistant (tool calls) + 2 tool messages + final assistant
+	if len(bob.Params.Messages) != 5 {
+		t.Errorf("😡 Expected 5 messages, got %d", len(bob.Params.Messages))
+	}
+	secondRequest := server.ChatRequests()[1]
+	if messages := secondRequest.Messages; len(messages) != 4 || messages[3].Role != "tool" {
+		t.Errorf("😡 Expected the tool results to be sent back, got %+v", messages)
+	}
+}
+
+// go test -v -run TestRunMaxIterations
+func TestRunMaxIterations(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("ping", `{}`)),
+		agentstest.ToolCalls(agentstest.Call("ping", `{}`)),
+		agentstest.Text("done"),
+	)
+
+	toolsDuringTheFinalAnswer := 0
+	bob, err := NewAgent("Bob",
+		WithDMR(server.URL),
+		WithModel("test"),
+		WithTools([]openai.ChatCompletionToolParam{{
+			Function: openai.FunctionDefinitionParam{Name: "ping"},
+		}}),
+		WithBeforeChatCompletion(func(ctx *ChatCompletionContext) {
+			toolsDuringTheFinalAnswer = len(ctx.Agent.Params.Tools)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	result, err := bob.Run(context.Background(), "ping", RunOptions{
+		MaxIterations: 2,
+		ToolsImpl: map[string]func(any) (any, error){
+			"ping": func(args any) (any, error) { return "pong", nil },
+		},
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if !result.MaxIterationsReached || result.Answer != "done" {
+		t.Errorf("😡 Unexpected result: %+v", result)
+	}
+	// The final answer is requested without tools
+	if _, ok := server.ChatRequests()[2].Params["tools"]; ok {
+		t.Errorf("😡 Expected no tools for the final answer")
+	}
+	// NOTE: the tools of the agent are never removed (the agent can be used concurrently)
+	if toolsDuringTheFinalAnswer != 1 || len(bob.Params.Tools) != 1 {
+		t.Errorf("😡 Expected the tools of the agent to be kept, got %d during the final answer", toolsDuringTheFinalAnswer)
+	}
+}
+
+// go test -v -run TestRunHandlers
+func TestRunHandlers(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("ping", "")),
+		agentstest.Text("done"),
+	)
+
+	events := []string{}
+	bob, err := NewAgent("Bob",
+		WithDMR(server.URL),
+		WithModel("test"),
+		WithTools([]openai.ChatCompletionToolParam{{
+			Function: openai.FunctionDefinitionParam{Name: "ping"},
+		}}),
+		WithBeforeToolsCompletion(func(ctx *ToolsCompletionContext) { events = append(events, "before tools") }),
+		WithAfterToolsCompletion(func(ctx *ToolsCompletionContext) {
+			events = append(events, "after tools")
+			if len(*ctx.ToolCalls) > 0 {
+				(*ctx.ToolCalls)[0].Function.Arguments = `{"from":"handler"}`
+			}
+		}),
+		WithBeforeChatCompletion(func(ctx *ChatCompletionContext) { events = append(events, "before chat") }),
+		WithAfterChatCompletion(func(ctx *ChatCompletionContext) { events = append(events, "after chat") }),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	result, err := bob.Run(context.Background(), "ping", RunOptions{
+		MaxIterations: 1,
+		ToolsImpl: map[string]func(any) (any, error){
+			"ping": func(args any) (any, error) { return args.(map[string]any)["from"], nil },
+		},
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if result.Answer != "done" || result.Steps[0].Results[0] != "handler" {
+		t.Errorf("😡 Expected the tool calls modified by the handler, got %q", result.Steps[0].Results[0])
+	}
+	if strings.Join(events, ",") != "before tools,after tools,before chat,after chat" {
+		t.Errorf("😡 Unexpected handler calls: %v", events)
+	}
+
+	// NOTE: empty arguments are decoded as {}
+	responses, err := bob.ExecuteToolCallsContext(context.Background(), []openai.ChatCompletionMessageToolCall{{
+		ID:       "call_empty",
+		Function: openai.ChatCompletionMessageToolCallFunction{Name: "ping", Arguments: ""},
+	}}, map[string]func(any) (any, error){
+		"ping": func(args any) (any, error) { return len(args.(map[string]any)), nil },
+	})
+	if err != nil || responses[0] != "0" {
+		t.Errorf("😡 Expected empty arguments to be accepted, got %v %v", responses, err)
+	}
+}
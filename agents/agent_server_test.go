@@ -0,0 +1,134 @@
+package agents
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+)
+
+// go test -v -run TestAgentServerShutdown
+func TestAgentServerShutdown(t *testing.T) {
+	model, modelStopped := newEndlessModelServer(t)
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithModel("test"),
+		WithHTTPServer(HTTPServerConfig{Port: "0"}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	agentServer, err := bob.StartHttpServerAsync()
+	if err != nil {
+		t.Fatalf("😡 Failed to start the server: %v", err)
+	}
+	url := "http://" + agentServer.Addr()
+
+	response, err := http.Post(url+"/api/chat-stream", "application/json", strings.NewReader(`{"user": "talk forever"}`))
+	if err != nil {
+		t.Fatalf("😡 Failed to call the stream endpoint: %v", err)
+	}
+	defer response.Body.Close()
+	buffer := make([]byte, 8)
+	response.Body.Read(buffer)
+
+	// The in-flight completion does not end: the graceful shutdown times out and the completion is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := bob.Close(ctx); err == nil {
+		t.Errorf("😡 Expected the graceful shutdown to time out")
+	}
+	io.ReadAll(response.Body)
+
+	select {
+	case <-modelStopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("😡 Expected the model request to be cancelled")
+	}
+	if err := agentServer.Wait(); err != nil {
+		t.Errorf("😡 Expected the server to be stopped without error, got %v", err)
+	}
+	if _, err := http.Get(url + "/api/sessions"); err == nil {
+		t.Errorf("😡 Expected the server to be stopped")
+	}
+}
+
+// go test -v -run TestAgentServerGracefulShutdown
+func TestAgentServerGracefulShutdown(t *testing.T) {
+	model := agentstest.NewServer(t, agentstest.Text("Hello"))
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithModel("test"),
+		WithHTTPServer(HTTPServerConfig{Port: "0"}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	agentServer, err := bob.StartHttpServerAsync()
+	if err != nil {
+		t.Fatalf("😡 Failed to start the server: %v", err)
+	}
+
+	answer, _ := postChat(t, "http://"+agentServer.Addr()+"/api/chat", "", `{"user": "Hello"}`)
+	if answer != "Hello" {
+		t.Errorf("😡 Unexpected answer: %s", answer)
+	}
+
+	if err := agentServer.Shutdown(context.Background()); err != nil {
+		t.Errorf("😡 Failed to shut down the server: %v", err)
+	}
+	if err := bob.Close(context.Background()); err != nil {
+		t.Errorf("😡 Failed to close the agent: %v", err)
+	}
+}
+
+// go test -v -run TestA2AServerShutdownWaitsForTheTasks
+func TestA2AServerShutdownWaitsForTheTasks(t *testing.T) {
+	release := make(chan struct{})
+	completed := make(chan struct{})
+	bob, err := NewAgent("Bob",
+		WithA2AServer(A2AServerConfig{Port: "0"}),
+		WithAgentCallback(func(ctx *AgentCallbackContext) (TaskResponse, error) {
+			<-release
+			close(completed)
+			return a2aAnswer("Done", ""), nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	agentServer, err := bob.StartA2AServerAsync()
+	if err != nil {
+		t.Fatalf("😡 Failed to start the server: %v", err)
+	}
+
+	blocking := false
+	request := a2aMessage("research", "")
+	request.Params.Configuration = &MessageSendConfiguration{Blocking: &blocking}
+	response, err := bob.SendToAgent("http://"+agentServer.Addr(), request)
+	if err != nil || response.Result.Status.State != TaskStateSubmitted {
+		t.Fatalf("😡 Expected a submitted task: %v %+v", err, response)
+	}
+
+	// The non-blocking task is still running: the graceful shutdown waits for it
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	if err := agentServer.Shutdown(context.Background()); err != nil {
+		t.Errorf("😡 Failed to shut down the server: %v", err)
+	}
+	select {
+	case <-completed:
+	default:
+		t.Fatalf("😡 Expected the shutdown to wait for the task")
+	}
+	task, _ := bob.a2aTasks.store.Get(context.Background(), response.Result.ID)
+	if task.Status.State != TaskStateCompleted {
+		t.Errorf("😡 Expected the task to be completed, got %s", task.Status.State)
+	}
+}
@@ -0,0 +1,150 @@
+package agents
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+// toolCallsStreamResponse streams the content and the tool calls "add" (10 + 32) and "ping"
+// (the arguments of each tool call are sent in two chunks).
+func toolCallsStreamResponse() agentstest.Response {
+	return agentstest.ToolCalls(
+		agentstest.ToolCall{ID: "call_add", Name: "add", Arguments: `{"a":10,"b":32}`},
+		agentstest.ToolCall{ID: "call_ping", Name: "ping", Arguments: `{}`},
+	).WithContent("Let me compute. ")
+}
+
+func newStreamToolsAgent(t *testing.T, model *agentstest.Server, options ...AgentOption) *Agent {
+	bob, err := NewAgent("Bob", append([]AgentOption{
+		WithDMR(model.URL),
+		WithModel("test"),
+		RegisterTool("add", "add two numbers", func(args addArgs) (float64, error) {
+			return args.A + args.B, nil
+		}),
+		RegisterTool("ping", "ping", func(args struct{}) (string, error) {
+			return "pong", nil
+		}),
+	}, options...)...)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	return bob
+}
+
+// go test -v -run TestChatCompletionStreamWithTools
+func TestChatCompletionStreamWithTools(t *testing.T) {
+	server := agentstest.NewServer(t, toolCallsStreamResponse())
+	bob := newStreamToolsAgent(t, server)
+	bob.AddUserMessage("Add 10 and 32, then ping")
+
+	streamed := ""
+	result, err := bob.ChatCompletionStreamWithTools(context.Background(), func(self *Agent, content string, err error) error {
+		streamed += content
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to stream: %v", err)
+	}
+	if streamed != "Let me compute. " || result.Content != streamed {
+		t.Errorf("😡 Unexpected content: streamed %q, result %q", streamed, result.Content)
+	}
+	if len(result.ToolCalls) != 2 {
+		t.Fatalf("😡 Expected 2 tool calls, got %+v", result.ToolCalls)
+	}
+	add := result.ToolCalls[0]
+	if add.ID != "call_add" || add.Function.Name != "add" || add.Function.Arguments != `{"a":10,"b":32}` {
+		t.Errorf("😡 Unexpected accumulated tool call: %+v", add)
+	}
+	if ping := result.ToolCalls[1]; ping.ID != "call_ping" || ping.Function.Name != "ping" || ping.Type != "function" {
+		t.Errorf("😡 Unexpected accumulated tool call: %+v", ping)
+	}
+}
+
+// go test -v -run TestChatCompletionStreamWithToolsSparseIndexes
+func TestChatCompletionStreamWithToolsSparseIndexes(t *testing.T) {
+	// NOTE: the indexes are keys, a huge index does not allocate the tool calls before it
+	var toolCalls toolCallAccumulator
+	toolCalls.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{
+		{Index: 2000000000, ID: "call_ping", Function: openai.ChatCompletionChunkChoiceDeltaToolCallFunction{Name: "ping"}},
+	})
+	toolCalls.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{
+		{Index: 7, ID: "call_add", Function: openai.ChatCompletionChunkChoiceDeltaToolCallFunction{Name: "add", Arguments: `{"a":1,`}},
+	})
+	toolCalls.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{
+		{Index: 2000000000, Function: openai.ChatCompletionChunkChoiceDeltaToolCallFunction{Arguments: "{}"}},
+		{Index: 7, Function: openai.ChatCompletionChunkChoiceDeltaToolCallFunction{Arguments: `"b":2}`}},
+	})
+
+	if len(toolCalls.toolCalls) != 2 {
+		t.Fatalf("😡 Expected 2 tool calls, got %d", len(toolCalls.toolCalls))
+	}
+	if ping := toolCalls.toolCalls[0]; ping.ID != "call_ping" || ping.Function.Name != "ping" || ping.Function.Arguments != "{}" {
+		t.Errorf("😡 Unexpected accumulated tool call: %+v", ping)
+	}
+	if add := toolCalls.toolCalls[1]; add.ID != "call_add" || add.Function.Arguments != `{"a":1,"b":2}` {
+		t.Errorf("😡 Unexpected accumulated tool call: %+v", add)
+	}
+}
+
+// go test -v -run TestRunStream
+func TestRunStream(t *testing.T) {
+	server := agentstest.NewServer(t,
+		toolCallsStreamResponse(),
+		agentstest.Stream("The result", " is 42"),
+	)
+	bob := newStreamToolsAgent(t, server)
+
+	streamed := ""
+	result, err := bob.RunStream(context.Background(), "Add 10 and 32, then ping", RunOptions{}, func(self *Agent, content string, err error) error {
+		streamed += content
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if result.Answer != "The result is 42" || streamed != "Let me compute. The result is 42" {
+		t.Errorf("😡 Unexpected answer %q (streamed %q)", result.Answer, streamed)
+	}
+	if len(result.Steps) != 1 || strings.Join(result.Steps[0].Results, ",") != "42,pong" {
+		t.Fatalf("😡 Expected 1 step with the results 42 and pong, got %+v", result.Steps)
+	}
+
+	// user + assistant (content and tool calls) + 2 tool messages + final assistant
+	if len(bob.Params.Messages) != 5 {
+		t.Errorf("😡 Expected 5 messages, got %d", len(bob.Params.Messages))
+	}
+	messages := server.ChatRequests()[1].Messages
+	if len(messages) != 4 {
+		t.Fatalf("😡 Expected the tool results to be sent back, got %d messages", len(messages))
+	}
+	if assistant := messages[1]; len(assistant.ToolCalls) != 2 || assistant.Content != "Let me compute. " {
+		t.Errorf("😡 Unexpected assistant message: %+v", assistant)
+	}
+	if toolMessage := messages[2]; toolMessage.ToolCallID != "call_add" || toolMessage.Content != "42" {
+		t.Errorf("😡 Unexpected tool message: %+v", toolMessage)
+	}
+}
+
+// go test -v -run TestHTTPServerStreamWithTools
+func TestHTTPServerStreamWithTools(t *testing.T) {
+	server := agentstest.NewServer(t,
+		toolCallsStreamResponse(),
+		agentstest.Stream("The result is 42"),
+	)
+	bob := newStreamToolsAgent(t, server, WithHTTPServer(HTTPServerConfig{}))
+	httpServer := httptest.NewServer(bob.HttpServer())
+	defer httpServer.Close()
+
+	answer, _ := postChat(t, httpServer.URL+"/api/chat-stream", "raw", `{"user": "Add 10 and 32, then ping"}`)
+	if answer != "Let me compute. The result is 42" {
+		t.Errorf("😡 Unexpected stream: %q", answer)
+	}
+	if len(server.ChatRequests()) != 2 {
+		t.Errorf("😡 Expected the tool results to be sent back to the model, got %d requests", len(server.ChatRequests()))
+	}
+}
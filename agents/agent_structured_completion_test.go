@@ -0,0 +1,90 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+type starTrekCharacter struct {
+	Name    string   `json:"name" description:"The name of the character"`
+	Species string   `json:"species" enum:"human,vulcan,klingon"`
+	Rank    int      `json:"rank"`
+	Ships   []string `json:"ships,omitempty"`
+}
+
+// go test -v -run TestStructuredCompletion
+func TestStructuredCompletion(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.Text(`{"name": "Spock", "species": "romulan", "rank": 2.5}`),
+		agentstest.Text("```json\n{\"name\": \"Spock\", \"species\": \"vulcan\", \"rank\": 2, \"ships\": [\"Enterprise\"]}\n```"),
+	)
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithParams(openai.ChatCompletionNewParams{
+			Model:    "test",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Who is Spock?")},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	character, err := StructuredCompletion[starTrekCharacter](context.Background(), bob, StructuredCompletionOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("😡 Failed to get the structured completion: %v", err)
+	}
+	if character.Name != "Spock" || character.Species != "vulcan" || character.Rank != 2 || len(character.Ships) != 1 {
+		t.Errorf("😡 Unexpected character: %+v", character)
+	}
+
+	requests := model.ChatRequests()
+	if len(requests) != 2 {
+		t.Fatalf("😡 Expected a retry, got %d requests", len(requests))
+	}
+	jsonSchema := requests[0].Params["response_format"].(map[string]any)["json_schema"].(map[string]any)
+	if jsonSchema["name"] != "starTrekCharacter" || jsonSchema["strict"] != true {
+		t.Errorf("😡 Unexpected response format: %v", jsonSchema)
+	}
+	// Strict mode: all the properties are required, the optional ones are nullable
+	schema := jsonSchema["schema"].(map[string]any)
+	ships := schema["properties"].(map[string]any)["ships"].(map[string]any)
+	if len(schema["required"].([]any)) != 4 || len(ships["type"].([]any)) != 2 {
+		t.Errorf("😡 Unexpected strict schema: %v", schema)
+	}
+	// The validation errors are sent back to the model
+	feedback := requests[1].LastMessage().Content
+	if !strings.Contains(feedback, `$.species: romulan is not one of [human vulcan klingon]`) ||
+		!strings.Contains(feedback, "$.rank: expected integer, got number") {
+		t.Errorf("😡 Unexpected feedback: %s", feedback)
+	}
+	if len(bob.Params.Messages) != 1 || bob.Params.ResponseFormat.OfJSONSchema != nil {
+		t.Errorf("😡 Expected the agent to be unchanged")
+	}
+
+	// The retries are limited
+	model = agentstest.NewServer(t, agentstest.Text("I don't know"))
+	bob, _ = NewAgent("Bob", WithDMR(model.URL), WithModel("test"))
+	_, err = StructuredCompletion[starTrekCharacter](context.Background(), bob, StructuredCompletionOptions{MaxRetries: -1})
+	if !errors.Is(err, ErrInvalidStructuredOutput) {
+		t.Errorf("😡 Expected an invalid structured output error, got %v", err)
+	}
+
+	// The structured output is an object
+	if _, err := StructuredCompletion[[]string](context.Background(), bob); err == nil {
+		t.Errorf("😡 Expected an error for a non object type")
+	}
+
+	// The strict mode does not support the maps
+	type crew struct {
+		Ranks map[string]int `json:"ranks"`
+	}
+	if _, err := StructuredCompletion[crew](context.Background(), bob, StructuredCompletionOptions{Strict: true}); err == nil || len(model.ChatRequests()) != 1 {
+		t.Errorf("😡 Expected an error for a map without request to the model, got %v", err)
+	}
+}
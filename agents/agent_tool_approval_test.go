@@ -0,0 +1,264 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+// go test -v -run TestToolPolicyByName
+func TestToolPolicyByName(t *testing.T) {
+	policy := ToolPolicyByName(map[string]ToolDecision{
+		"read_*":      ToolAutoRun,
+		"*_file":      ToolConfirm,
+		"delete_*":    ToolDeny,
+		"delete_temp": ToolAutoRun,
+	}, ToolConfirm)
+
+	for toolName, expected := range map[string]ToolDecision{
+		"read_config": ToolAutoRun,
+		"read_file":   ToolConfirm, // the strictest pattern wins
+		"delete_file": ToolDeny,
+		"delete_temp": ToolAutoRun, // an exact name wins over the patterns
+		"say_hello":   ToolConfirm, // fallback
+	} {
+		if decision := policy(ToolApprovalRequest{ToolName: toolName}); decision != expected {
+			t.Errorf("😡 Expected %s for %s, got %s", expected, toolName, decision)
+		}
+	}
+}
+
+func newApprovalAgent(t *testing.T, model *agentstest.Server, executed *[]string, options ...AgentOption) *Agent {
+	tool := func(name string) func(args struct {
+		Path string `json:"path"`
+	}) (string, error) {
+		return func(args struct {
+			Path string `json:"path"`
+		}) (string, error) {
+			*executed = append(*executed, name)
+			return name + " done", nil
+		}
+	}
+	bob, err := NewAgent("Bob", append([]AgentOption{
+		WithDMR(model.URL),
+		WithModel("test"),
+		RegisterTool("read_file", "read a file", tool("read_file")),
+		RegisterTool("delete_file", "delete a file", tool("delete_file")),
+		RegisterTool("drop_db", "drop the database", tool("drop_db")),
+		WithToolPolicy(ToolPolicyByName(map[string]ToolDecision{
+			"read_*":  ToolAutoRun,
+			"drop_db": ToolDeny,
+		}, ToolConfirm)),
+	}, options...)...)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	return bob
+}
+
+// go test -v -run TestRunToolApproval
+func TestRunToolApproval(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(
+			agentstest.Call("read_file", `{"path":"a.txt"}`),
+			agentstest.Call("delete_file", `{"path":"a.txt"}`),
+			agentstest.Call("drop_db", `{}`),
+		),
+		agentstest.Text("I could only read the file"),
+	)
+	approvals := []ToolApprovalRequest{}
+	executed := []string{}
+	bob := newApprovalAgent(t, server, &executed, WithToolApprover(func(ctx context.Context, request ToolApprovalRequest) (ToolApproval, error) {
+		approvals = append(approvals, request)
+		return ToolApproval{Reason: "not today"}, nil
+	}))
+
+	result, err := bob.Run(context.Background(), "Read then delete a.txt, and drop the database", RunOptions{})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if strings.Join(executed, ",") != "read_file" {
+		t.Errorf("😡 Only read_file should be executed, got %v", executed)
+	}
+	if len(approvals) != 1 || approvals[0].ToolName != "delete_file" || approvals[0].Arguments["path"] != "a.txt" || approvals[0].AgentName != "Bob" {
+		t.Errorf("😡 Expected the approval of delete_file, got %+v", approvals)
+	}
+	results := result.Steps[0].Results
+	if results[0] != "read_file done" {
+		t.Errorf("😡 Unexpected result: %s", results[0])
+	}
+	if results[1] != "error: tool call denied: delete_file (not today)" {
+		t.Errorf("😡 Unexpected denial: %s", results[1])
+	}
+	if results[2] != "error: tool call denied: drop_db" {
+		t.Errorf("😡 Unexpected denial: %s", results[2])
+	}
+	// The denials are sent back to the model as tool messages
+	if messages := server.ChatRequests()[1].Messages; len(messages) != 5 {
+		t.Errorf("😡 Expected the tool messages to be sent back, got %d messages", len(messages))
+	}
+}
+
+// go test -v -run TestExecuteToolCallsWithoutApprover
+func TestExecuteToolCallsWithoutApprover(t *testing.T) {
+	server := agentstest.NewServer(t)
+	executed := []string{}
+	bob := newApprovalAgent(t, server, &executed)
+
+	responses, err := bob.ExecuteToolCallsContext(context.Background(), []openai.ChatCompletionMessageToolCall{
+		{ID: "call_a", Function: openai.ChatCompletionMessageToolCallFunction{Name: "delete_file", Arguments: `{"path":"a.txt"}`}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("😡 Failed to execute the tool calls: %v", err)
+	}
+	if len(executed) != 0 || !strings.Contains(responses[0], "no approver") {
+		t.Errorf("😡 The tool call should be denied without approver: %v %v", executed, responses)
+	}
+	if len(bob.Params.Messages) != 1 {
+		t.Errorf("😡 Expected a tool message, got %d messages", len(bob.Params.Messages))
+	}
+}
+
+// go test -v -run TestHTTPToolApprover
+func TestHTTPToolApprover(t *testing.T) {
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var request ToolApprovalRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		json.NewEncoder(w).Encode(ToolApproval{Approved: request.Arguments["path"] == "tmp.txt", Reason: "protected file"})
+	}))
+	defer callback.Close()
+
+	approver := HTTPToolApprover(callback.URL, map[string]string{"Authorization": "Bearer secret"})
+	approval, err := approver(context.Background(), ToolApprovalRequest{ToolName: "delete_file", Arguments: map[string]any{"path": "tmp.txt"}})
+	if err != nil || !approval.Approved {
+		t.Errorf("😡 Expected an approval: %v %+v", err, approval)
+	}
+	approval, err = approver(context.Background(), ToolApprovalRequest{ToolName: "delete_file", Arguments: map[string]any{"path": "a.txt"}})
+	if err != nil || approval.Approved || approval.Reason != "protected file" {
+		t.Errorf("😡 Expected a denial: %v %+v", err, approval)
+	}
+
+	// A failing callback denies the tool call
+	server := agentstest.NewServer(t)
+	executed := []string{}
+	bob := newApprovalAgent(t, server, &executed, WithToolApprover(HTTPToolApprover(callback.URL, nil)))
+	responses, _ := bob.ExecuteToolCallsContext(context.Background(), []openai.ChatCompletionMessageToolCall{
+		{ID: "call_a", Function: openai.ChatCompletionMessageToolCallFunction{Name: "delete_file", Arguments: `{"path":"tmp.txt"}`}},
+	}, nil)
+	if len(executed) != 0 || !strings.Contains(responses[0], "401 Unauthorized") {
+		t.Errorf("😡 Expected a denial, got %v %v", executed, responses)
+	}
+}
+
+// go test -v -run TestA2AToolApprover
+func TestA2AToolApprover(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("delete_file", `{"path":"a.txt"}`)),
+		// The callback runs again with the answer of the client
+		agentstest.ToolCalls(agentstest.Call("delete_file", `{"path":"a.txt"}`)),
+		agentstest.Text("a.txt is deleted"),
+	)
+	executed := []string{}
+	var bob *Agent
+	bob = newApprovalAgent(t, model, &executed,
+		WithToolApprover(A2AToolApprover()),
+		WithA2AServer(A2AServerConfig{}),
+		WithAgentCallback(func(ctx *AgentCallbackContext) (TaskResponse, error) {
+			bob.Params.Messages = nil
+			result, err := bob.Run(ctx.Context, "Delete a.txt", RunOptions{})
+			if err != nil {
+				return TaskResponse{}, err
+			}
+			return a2aAnswer(result.Answer, ""), nil
+		}),
+	)
+	server := httptest.NewServer(bob.A2AHandler())
+	defer server.Close()
+
+	response, err := bob.SendToAgent(server.URL, a2aMessage("Delete a.txt", ""))
+	if err != nil {
+		t.Fatalf("😡 Failed to send the message: %v", err)
+	}
+	if response.Result.Status.State != TaskStateInputRequired || len(executed) != 0 {
+		t.Fatalf("😡 Expected an input-required task, got %s (executed: %v)", response.Result.Status.State, executed)
+	}
+	if question := response.Result.Status.Message.Text(); !strings.Contains(question, "delete_file") || !strings.Contains(question, "a.txt") {
+		t.Errorf("😡 Unexpected question: %s", question)
+	}
+
+	response, err = bob.SendToAgent(server.URL, a2aMessage("yes", response.Result.ID))
+	if err != nil || response.Result.Status.State != TaskStateCompleted {
+		t.Fatalf("😡 Expected a completed task: %v %+v", err, response)
+	}
+	if strings.Join(executed, ",") != "delete_file" {
+		t.Errorf("😡 Expected delete_file to be executed once approved, got %v", executed)
+	}
+	task, _ := NewA2AClient(A2AClientConfig{}).GetTask(context.Background(), server.URL, response.Result.ID)
+	if _, ok := task.Result.Metadata[a2aToolApprovalMetadataKey]; ok {
+		t.Errorf("😡 The pending approval should be removed: %+v", task.Result.Metadata)
+	}
+
+	// Without pending approval, the approver always requires one
+	_, err = A2AToolApprover()(context.Background(), ToolApprovalRequest{ToolName: "delete_file"})
+	var approvalRequired *ToolApprovalRequiredError
+	if !errors.As(err, &approvalRequired) {
+		t.Errorf("😡 Expected a ToolApprovalRequiredError, got %v", err)
+	}
+}
+
+// go test -v -run TestA2AToolApproverRetries
+func TestA2AToolApproverRetries(t *testing.T) {
+	// The model changes the arguments of the tool call each time the callback runs again
+	model := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("delete_file", `{"path":"a.txt"}`)),
+		agentstest.ToolCalls(agentstest.Call("delete_file", `{"path":"./a.txt"}`)),
+		agentstest.ToolCalls(agentstest.Call("delete_file", `{"path":"docs/../a.txt"}`)),
+		agentstest.ToolCalls(agentstest.Call("delete_file", `{"path":"/tmp/a.txt"}`)),
+	)
+	executed := []string{}
+	var bob *Agent
+	bob = newApprovalAgent(t, model, &executed,
+		WithToolApprover(A2AToolApprover()),
+		WithA2AServer(A2AServerConfig{}),
+		WithAgentCallback(func(ctx *AgentCallbackContext) (TaskResponse, error) {
+			bob.Params.Messages = nil
+			result, err := bob.Run(ctx.Context, "Delete a.txt", RunOptions{})
+			if err != nil {
+				return TaskResponse{}, err
+			}
+			return a2aAnswer(result.Answer, ""), nil
+		}),
+	)
+	server := httptest.NewServer(bob.A2AHandler())
+	defer server.Close()
+
+	response, err := bob.SendToAgent(server.URL, a2aMessage("Delete a.txt", ""))
+	if err != nil || response.Result.Status.State != TaskStateInputRequired {
+		t.Fatalf("😡 Expected an input-required task: %v %+v", err, response)
+	}
+	// The answer never applies: the approval is asked again A2AMaxToolApprovalRetries times, then the task fails
+	for range A2AMaxToolApprovalRetries {
+		response, err = bob.SendToAgent(server.URL, a2aMessage("yes", response.Result.ID))
+		if err != nil || response.Result.Status.State != TaskStateInputRequired {
+			t.Fatalf("😡 Expected the approval to be asked again: %v %+v", err, response)
+		}
+	}
+	response, err = bob.SendToAgent(server.URL, a2aMessage("yes", response.Result.ID))
+	if err != nil || response.Result.Status.State != TaskStateFailed {
+		t.Fatalf("😡 Expected a failed task: %v %+v", err, response)
+	}
+	if len(executed) != 0 {
+		t.Errorf("😡 Expected no tool execution, got %v", executed)
+	}
+}
@@ -0,0 +1,173 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+type addArgs struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+func newValidationAgent(t *testing.T, model *agentstest.Server, calls *int, options ...AgentOption) *Agent {
+	bob, err := NewAgent("Bob", append([]AgentOption{
+		WithDMR(model.URL),
+		WithModel("test"),
+		RegisterTool("add", "add two numbers", func(args addArgs) (float64, error) {
+			*calls++
+			return args.A + args.B, nil
+		}),
+	}, options...)...)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	return bob
+}
+
+func addToolCall(arguments string) []openai.ChatCompletionMessageToolCall {
+	return []openai.ChatCompletionMessageToolCall{
+		{ID: "call_a", Function: openai.ChatCompletionMessageToolCallFunction{Name: "add", Arguments: arguments}},
+	}
+}
+
+// go test -v -run TestRunToolArgumentsRepair
+func TestRunToolArgumentsRepair(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("add", `{"a":"10"}`)),
+		agentstest.ToolCalls(agentstest.Call("add", `{"a":10,"b":32}`)),
+		agentstest.Text("The result is 42"),
+	)
+	calls := 0
+	bob := newValidationAgent(t, server, &calls)
+
+	result, err := bob.Run(context.Background(), "Add 10 and 32", RunOptions{})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if calls != 1 || result.Answer != "The result is 42" || len(result.Steps) != 2 {
+		t.Fatalf("😡 Expected the tool to be called once with the fixed arguments: %d calls, %+v", calls, result)
+	}
+	repair := result.Steps[0].Results[0]
+	for _, expected := range []string{
+		"error: invalid tool arguments for tool add:",
+		`$: missing required property "b"`,
+		"$.a: expected number, got string",
+		`"required":["a","b"]`,
+		"Fix the arguments and call the tool add again.",
+	} {
+		if !strings.Contains(repair, expected) {
+			t.Errorf("😡 Expected %q in the tool message: %s", expected, repair)
+		}
+	}
+	if result.Steps[1].Results[0] != "42" {
+		t.Errorf("😡 Unexpected result: %s", result.Steps[1].Results[0])
+	}
+}
+
+// go test -v -run TestToolArgumentsRepairAttempts
+func TestToolArgumentsRepairAttempts(t *testing.T) {
+	calls := 0
+	bob := newValidationAgent(t, agentstest.NewServer(t), &calls, WithToolValidation(ToolValidationConfig{MaxRepairAttempts: 1}))
+	ctx := context.Background()
+
+	responses, _ := bob.ExecuteToolCallsContext(ctx, addToolCall(`{"a":10}`), nil)
+	if !strings.Contains(responses[0], "Fix the arguments") {
+		t.Errorf("😡 Expected a repair request: %s", responses[0])
+	}
+	responses, _ = bob.ExecuteToolCallsContext(ctx, addToolCall(`{"a":10}`), nil)
+	if !strings.Contains(responses[0], "still invalid after 2 attempts: do not call the tool add again") {
+		t.Errorf("😡 Expected the end of the repairs: %s", responses[0])
+	}
+
+	// A valid call resets the attempts
+	responses, _ = bob.ExecuteToolCallsContext(ctx, addToolCall(`{"a":10,"b":32}`), nil)
+	if responses[0] != "42" || calls != 1 {
+		t.Errorf("😡 Unexpected result: %s (%d calls)", responses[0], calls)
+	}
+	responses, _ = bob.ExecuteToolCallsContext(ctx, addToolCall(`{"b":32}`), nil)
+	if !strings.Contains(responses[0], "Fix the arguments") {
+		t.Errorf("😡 Expected a repair request: %s", responses[0])
+	}
+
+	// Each copy of the agent (e.g. an HTTP session) counts its own attempts
+	bob.ExecuteToolCallsContext(ctx, addToolCall(`{"b":32}`), nil)
+	responses, _ = bob.withMessages(nil).ExecuteToolCallsContext(ctx, addToolCall(`{"b":32}`), nil)
+	if !strings.Contains(responses[0], "Fix the arguments") {
+		t.Errorf("😡 Expected a repair request for the copy of the agent: %s", responses[0])
+	}
+
+	_, err := bob.executeToolCall(ctx, "add", map[string]any{"a": true, "b": 1}, nil)
+	var argumentsErr *ToolArgumentsError
+	if !errors.As(err, &argumentsErr) || !errors.Is(err, ErrInvalidToolArguments) || argumentsErr.Errors[0] != "$.a: expected number, got boolean" {
+		t.Errorf("😡 Expected a ToolArgumentsError, got %v", err)
+	}
+
+	// Without validation, the tool gets the arguments of the model
+	bob = newValidationAgent(t, agentstest.NewServer(t), &calls, WithToolValidation(ToolValidationConfig{Disabled: true}))
+	responses, _ = bob.ExecuteToolCallsContext(ctx, addToolCall(`{"a":10}`), nil)
+	if responses[0] != "10" || calls != 2 {
+		t.Errorf("😡 Expected the tool to be called: %s (%d calls)", responses[0], calls)
+	}
+}
+
+// go test -v -run TestRunResetsToolArgumentsRepairAttempts
+func TestRunResetsToolArgumentsRepairAttempts(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("add", `{"a":10}`)),
+		agentstest.Text("I need b"),
+	)
+	calls := 0
+	bob := newValidationAgent(t, server, &calls, WithToolValidation(ToolValidationConfig{MaxRepairAttempts: 1}))
+	ctx := context.Background()
+
+	// The attempts of the previous tool calls are not counted by the Run
+	bob.ExecuteToolCallsContext(ctx, addToolCall(`{"a":10}`), nil)
+	bob.ExecuteToolCallsContext(ctx, addToolCall(`{"a":10}`), nil)
+	result, err := bob.Run(ctx, "Add 10", RunOptions{})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if !strings.Contains(result.Steps[0].Results[0], "Fix the arguments") {
+		t.Errorf("😡 Expected a repair request: %s", result.Steps[0].Results[0])
+	}
+}
+
+// go test -v -run TestMCPToolArgumentsValidation
+func TestMCPToolArgumentsValidation(t *testing.T) {
+	docs := newMCPSearchServer(t, "docs")
+	ctx := context.Background()
+	bob, err := NewAgent("Bob",
+		WithNamedMCPStreamableHttpClient(ctx, "docs", docs.URL+"/mcp", StreamableHttpOptions{}),
+		WithMCPClientTools(ctx, "docs", nil),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	defer bob.Close(ctx)
+
+	toolCalls := []openai.ChatCompletionMessageToolCall{
+		{ID: "call_a", Function: openai.ChatCompletionMessageToolCallFunction{Name: "search", Arguments: `{"query":42}`}},
+		{ID: "call_b", Function: openai.ChatCompletionMessageToolCallFunction{Name: "search", Arguments: `{"query":"budgie"}`}},
+	}
+	responses, err := bob.ExecuteToolCallsContext(ctx, toolCalls, nil)
+	if err != nil {
+		t.Fatalf("😡 Failed to execute the tool calls: %v", err)
+	}
+	if !strings.Contains(responses[0], "$.query: expected string, got integer") {
+		t.Errorf("😡 Expected a validation error: %s", responses[0])
+	}
+	if responses[1] != "docs: budgie" {
+		t.Errorf("😡 Unexpected result: %s", responses[1])
+	}
+
+	responses, _ = bob.ExecuteMCPToolCalls(ctx, "docs", toolCalls[:1])
+	if !strings.Contains(responses[0], "$.query: expected string, got integer") {
+		t.Errorf("😡 Expected a validation error: %s", responses[0])
+	}
+}
@@ -0,0 +1,139 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// go test -v -run TestExecuteToolCallsConcurrently
+func TestExecuteToolCallsConcurrently(t *testing.T) {
+	bob, err := NewAgent("Bob")
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	toolCalls := []openai.ChatCompletionMessageToolCall{}
+	for i := range 6 {
+		toolCalls = append(toolCalls, openai.ChatCompletionMessageToolCall{
+			ID: fmt.Sprintf("call_%d", i),
+			Function: openai.ChatCompletionMessageToolCallFunction{
+				Name:      "slow",
+				Arguments: fmt.Sprintf(`{"value": %d}`, i),
+			},
+		})
+	}
+	toolCalls = append(toolCalls, openai.ChatCompletionMessageToolCall{
+		ID:       "call_stuck",
+		Function: openai.ChatCompletionMessageToolCallFunction{Name: "stuck", Arguments: `{}`},
+	})
+
+	var running, maxRunning atomic.Int32
+	// The stuck tool call keeps running after its time out: the test waits for it
+	stuckDone := make(chan struct{})
+	defer func() { <-stuckDone }()
+	start := time.Now()
+	results, err := bob.ExecuteToolCallsConcurrently(context.Background(), toolCalls,
+		map[string]func(any) (any, error){
+			"slow": func(args any) (any, error) {
+				current := running.Add(1)
+				defer running.Add(-1)
+				for {
+					previous := maxRunning.Load()
+					if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+						break
+					}
+				}
+				value := args.(map[string]any)["value"].(float64)
+				// The first calls are the slowest ones
+				time.Sleep(time.Duration(60-10*value) * time.Millisecond)
+				return value, nil
+			},
+			"stuck": func(args any) (any, error) {
+				defer close(stuckDone)
+				time.Sleep(time.Second)
+				return "too late", nil
+			},
+		},
+		ConcurrentExecutionConfig{MaxWorkers: 3, Timeout: 200 * time.Millisecond},
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to execute tool calls: %v", err)
+	}
+	if time.Since(start) > 900*time.Millisecond {
+		t.Errorf("😡 Expected the stuck tool call to time out")
+	}
+	if maxRunning.Load() > 3 {
+		t.Errorf("😡 Expected at most 3 workers, got %d", maxRunning.Load())
+	}
+	if strings.Join(results[:6], ",") != "0,1,2,3,4,5" {
+		t.Errorf("😡 Expected ordered results, got %v", results)
+	}
+	if !strings.Contains(results[6], "timed out") {
+		t.Errorf("😡 Expected a time out, got %s", results[6])
+	}
+
+	if len(bob.Params.Messages) != 7 {
+		t.Fatalf("😡 Expected 7 tool messages, got %d", len(bob.Params.Messages))
+	}
+	for i, message := range bob.Params.Messages {
+		if message.OfTool == nil || message.OfTool.ToolCallID != toolCalls[i].ID {
+			t.Errorf("😡 Unexpected tool message at index %d", i)
+		}
+	}
+}
+
+// go test -v -run TestTimedOutToolCallKeepsItsWorker
+func TestTimedOutToolCallKeepsItsWorker(t *testing.T) {
+	bob, err := NewAgent("Bob")
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	toolCalls := []openai.ChatCompletionMessageToolCall{}
+	for i := range 3 {
+		toolCalls = append(toolCalls, openai.ChatCompletionMessageToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Function: openai.ChatCompletionMessageToolCallFunction{Name: "stuck", Arguments: `{}`},
+		})
+	}
+
+	var running, maxRunning atomic.Int32
+	// The timed out tool calls keep running: the test waits for them
+	stuckDone := make(chan struct{}, len(toolCalls))
+	defer func() {
+		for range toolCalls {
+			<-stuckDone
+		}
+	}()
+	results, err := bob.ExecuteToolCallsConcurrently(context.Background(), toolCalls,
+		map[string]func(any) (any, error){
+			"stuck": func(args any) (any, error) {
+				defer func() { stuckDone <- struct{}{} }()
+				if current := running.Add(1); current > maxRunning.Load() {
+					maxRunning.Store(current)
+				}
+				defer running.Add(-1)
+				time.Sleep(100 * time.Millisecond)
+				return "too late", nil
+			},
+		},
+		ConcurrentExecutionConfig{MaxWorkers: 1, Timeout: 20 * time.Millisecond},
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to execute tool calls: %v", err)
+	}
+	if maxRunning.Load() != 1 {
+		t.Errorf("😡 Expected the timed out tool calls to keep their worker, got %d running tools", maxRunning.Load())
+	}
+	for _, result := range results {
+		if !strings.Contains(result, "timed out") {
+			t.Errorf("😡 Expected a time out, got %s", result)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package agents
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+)
+
+type werewolfArgs struct {
+	Attribute string  `json:"attribute" description:"The attribute to update." enum:"health,intelligence"`
+	Amount    float64 `json:"amount" description:"The amount to add."`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+type werewolfResult struct {
+	Attribute string  `json:"attribute"`
+	Value     float64 `json:"value"`
+}
+
+// go test -v -run TestRegisterTool
+func TestRegisterTool(t *testing.T) {
+	werewolf := map[string]float64{"health": 100}
+
+	bob, err := NewAgent("Bob",
+		RegisterTool("increase", "Increase an attribute of the Werewolf", func(args werewolfArgs) (werewolfResult, error) {
+			werewolf[args.Attribute] += args.Amount
+			return werewolfResult{Attribute: args.Attribute, Value: werewolf[args.Attribute]}, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	if len(bob.Params.Tools) != 1 {
+		t.Fatalf("😡 Expected 1 tool, got %d", len(bob.Params.Tools))
+	}
+	schema := bob.Params.Tools[0].Function.Parameters
+	if !reflect.DeepEqual(schema["required"], []string{"attribute", "amount"}) {
+		t.Errorf("😡 Unexpected required properties: %v", schema["required"])
+	}
+	attribute := schema["properties"].(map[string]any)["attribute"].(map[string]any)
+	if attribute["type"] != "string" || attribute["description"] != "The attribute to update." {
+		t.Errorf("😡 Unexpected attribute property: %v", attribute)
+	}
+	if !reflect.DeepEqual(attribute["enum"], []string{"health", "intelligence"}) {
+		t.Errorf("😡 Unexpected enum: %v", attribute["enum"])
+	}
+
+	results, err := bob.ExecuteToolCalls([]openai.ChatCompletionMessageToolCall{{
+		ID: "call_1",
+		Function: openai.ChatCompletionMessageToolCallFunction{
+			Name:      "increase",
+			Arguments: `{"attribute": "health", "amount": 10}`,
+		},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("😡 Failed to execute tool calls: %v", err)
+	}
+	if results[0] != `{"attribute":"health","value":110}` {
+		t.Errorf("😡 Unexpected result: %s", results[0])
+	}
+}
+
+// go test -v -run TestRunWithRegisteredTools
+func TestRunWithRegisteredTools(t *testing.T) {
+	server := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("add", `{"a":40,"b":2}`)),
+		agentstest.Text("42"),
+	)
+
+	type addArgs struct {
+		A float64 `json:"a"`
+		B float64 `json:"b"`
+	}
+
+	bob, err := NewAgent("Bob",
+		WithDMR(server.URL),
+		WithModel("test"),
+		RegisterTool("add", "add two numbers", func(args addArgs) (float64, error) {
+			return args.A + args.B, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	result, err := bob.Run(context.Background(), "Add 40 and 2", RunOptions{})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if result.Steps[0].Results[0] != "42" {
+		t.Errorf("😡 Expected 42, got %s", result.Steps[0].Results[0])
+	}
+}
+
+// go test -v -run TestRegisterToolReplacesTool
+func TestRegisterToolReplacesTool(t *testing.T) {
+	type levelArgs struct {
+		Level int `json:"level" enum:"1,2,3"`
+	}
+
+	bob, err := NewAgent("Bob",
+		WithTools([]openai.ChatCompletionToolParam{{
+			Function: openai.FunctionDefinitionParam{Name: "level"},
+		}}),
+		RegisterTool("level", "set the level", func(args levelArgs) (int, error) {
+			return args.Level, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	if len(bob.Params.Tools) != 1 || bob.Params.Tools[0].Function.Parameters == nil {
+		t.Fatalf("😡 Expected the registered tool to replace the tool, got %+v", bob.Params.Tools)
+	}
+
+	// NOTE: the numeric enum accepts the numbers of the model
+	results, err := bob.ExecuteToolCalls([]openai.ChatCompletionMessageToolCall{
+		{ID: "call_1", Function: openai.ChatCompletionMessageToolCallFunction{Name: "level", Arguments: `{"level": 2}`}},
+		{ID: "call_2", Function: openai.ChatCompletionMessageToolCallFunction{Name: "level", Arguments: `{"level": 4}`}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("😡 Failed to execute tool calls: %v", err)
+	}
+	if results[0] != "2" || !strings.Contains(results[1], "4 is not one of [1 2 3]") {
+		t.Errorf("😡 Unexpected results: %q", results)
+	}
+}
+
+// go test -v -run TestToolReplacesRegisteredTool
+func TestToolReplacesRegisteredTool(t *testing.T) {
+	type levelArgs struct {
+		Level int `json:"level"`
+	}
+
+	bob, err := NewAgent("Bob",
+		RegisterTool("level", "set the level", func(args levelArgs) (int, error) {
+			return args.Level, nil
+		}),
+		WithTools([]openai.ChatCompletionToolParam{{
+			Function: openai.FunctionDefinitionParam{Name: "level"},
+		}}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	// The implementation of the replaced tool expects the arguments of the old schema: it is removed
+	if _, ok := bob.RegisteredTools()["level"]; ok {
+		t.Errorf("😡 Expected the registered implementation to be removed")
+	}
+	results, err := bob.ExecuteToolCalls([]openai.ChatCompletionMessageToolCall{
+		{ID: "call_1", Function: openai.ChatCompletionMessageToolCallFunction{Name: "level", Arguments: `{"level": 2}`}},
+	}, nil)
+	if err != nil || !strings.Contains(results[0], ErrToolNotImplemented.Error()) {
+		t.Errorf("😡 Expected the tool not to be implemented: %v %q", err, results)
+	}
+}
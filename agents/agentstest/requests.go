@@ -0,0 +1,173 @@
+package agentstest
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// Request is a request received by the Server, with the decoded fields of the chat and embedding requests.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+	Params map[string]any // The decoded JSON body
+
+	Model    string
+	Stream   bool
+	Messages []Message // Chat completions
+	Tools    []string  // Chat completions: names of the tools
+	Inputs   []string  // Embeddings: the input texts
+}
+
+// Message is a message of a chat completion request.
+type Message struct {
+	Role       string
+	Content    string // The text of the message (the text parts are joined with new lines)
+	ToolCallID string
+	ToolCalls  []ToolCall
+}
+
+func newRequest(r *http.Request, body []byte) Request {
+	request := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+	json.Unmarshal(body, &request.Params)
+
+	var params struct {
+		Model    string `json:"model"`
+		Stream   bool   `json:"stream"`
+		Messages []struct {
+			Role       string          `json:"role"`
+			Content    json.RawMessage `json:"content"`
+			ToolCallID string          `json:"tool_call_id"`
+			ToolCalls  []struct {
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+		Tools []struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		} `json:"tools"`
+		Input json.RawMessage `json:"input"`
+	}
+	json.Unmarshal(body, &params)
+
+	request.Model = params.Model
+	request.Stream = params.Stream
+	for _, message := range params.Messages {
+		requestMessage := Message{
+			Role:       message.Role,
+			Content:    textOf(message.Content),
+			ToolCallID: message.ToolCallID,
+		}
+		for _, call := range message.ToolCalls {
+			requestMessage.ToolCalls = append(requestMessage.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+		}
+		request.Messages = append(request.Messages, requestMessage)
+	}
+	for _, tool := range params.Tools {
+		request.Tools = append(request.Tools, tool.Function.Name)
+	}
+	var input string
+	if json.Unmarshal(params.Input, &input) == nil {
+		request.Inputs = []string{input}
+	} else {
+		json.Unmarshal(params.Input, &request.Inputs)
+	}
+	return request
+}
+
+// textOf returns the text of a message content: a string, or a list of parts.
+func textOf(content json.RawMessage) string {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		return text
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	json.Unmarshal(content, &parts)
+	texts := []string{}
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// LastMessage returns the last message of the request (a zero Message if there is none).
+func (request Request) LastMessage() Message {
+	if len(request.Messages) == 0 {
+		return Message{}
+	}
+	return request.Messages[len(request.Messages)-1]
+}
+
+// MessagesWithRole returns the messages of the role (e.g. "system", "user", "assistant" or "tool").
+func (request Request) MessagesWithRole(role string) []Message {
+	messages := []Message{}
+	for _, message := range request.Messages {
+		if message.Role == role {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// HasTool returns true if the tool is sent with the request.
+func (request Request) HasTool(name string) bool {
+	for _, tool := range request.Tools {
+		if tool == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Decode decodes the JSON body of the request into target.
+func (request Request) Decode(target any) error {
+	return json.Unmarshal(request.Body, target)
+}
+
+// EmbeddingDimensions is the number of dimensions of the embeddings created by Embedding.
+const EmbeddingDimensions = 64
+
+// Embedding is the default embedding of the server: a normalized bag of words (lower case, hashed),
+// so the texts sharing words are close with the cosine similarity, and the same text has always the same embedding.
+func Embedding(text string) []float64 {
+	embedding := make([]float64, EmbeddingDimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		hash := fnv.New32a()
+		hash.Write([]byte(word))
+		embedding[hash.Sum32()%EmbeddingDimensions]++
+	}
+	norm := 0.0
+	for _, value := range embedding {
+		norm += value * value
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range embedding {
+			embedding[i] /= norm
+		}
+	}
+	return embedding
+}
@@ -0,0 +1,138 @@
+package agentstest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Response is a scripted response of a chat completion (see Text, Stream, ToolCalls and Error).
+// The same response answers the streamed and the non-streamed requests.
+type Response struct {
+	content   string
+	streamed  []string
+	toolCalls []ToolCall
+	status    int
+	message   string
+}
+
+// ToolCall is a tool call of a scripted response, or of a message of a request.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON object
+}
+
+// Call returns a tool call of the tool name with the arguments (JSON object).
+// Its ID is "call_{position}" in the response.
+func Call(name string, arguments string) ToolCall {
+	return ToolCall{Name: name, Arguments: arguments}
+}
+
+// Text returns a response with the content. When the request is streamed, the content is sent word by word.
+func Text(content string) Response {
+	return Response{content: content}
+}
+
+// Stream returns a response with the content split in the given chunks when the request is streamed.
+func Stream(chunks ...string) Response {
+	return Response{content: strings.Join(chunks, ""), streamed: chunks}
+}
+
+// ToolCalls returns a response with the tool calls (finish reason: "tool_calls").
+// When the request is streamed, the arguments of each tool call are sent in two chunks, as the models do.
+func ToolCalls(calls ...ToolCall) Response {
+	return Response{toolCalls: calls}
+}
+
+// WithContent adds a content to a response with tool calls (sent before the tool calls when streamed).
+func (response Response) WithContent(content string) Response {
+	response.content = content
+	return response
+}
+
+// Error returns an error response with the HTTP status (e.g. http.StatusBadRequest) and the message.
+// NOTE: the OpenAI client retries the 408, 409, 429 and 5xx statuses twice by default, each retry uses the next
+// scripted response: create the client with option.WithMaxRetries(0) to script these errors.
+func Error(status int, message string) Response {
+	return Response{status: status, message: message}
+}
+
+func (response Response) finishReason() string {
+	if len(response.toolCalls) > 0 {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+func (response Response) toolCallID(i int) string {
+	if response.toolCalls[i].ID != "" {
+		return response.toolCalls[i].ID
+	}
+	return fmt.Sprintf("call_%d", i+1)
+}
+
+// completion returns the body of the non-streamed response.
+func (response Response) completion(model string) map[string]any {
+	message := map[string]any{"role": "assistant", "content": response.content}
+	if len(response.toolCalls) > 0 {
+		toolCalls := []map[string]any{}
+		for i, call := range response.toolCalls {
+			toolCalls = append(toolCalls, map[string]any{
+				"id":       response.toolCallID(i),
+				"type":     "function",
+				"function": map[string]any{"name": call.Name, "arguments": call.Arguments},
+			})
+		}
+		message["tool_calls"] = toolCalls
+	}
+	return map[string]any{
+		"id":      "chatcmpl-agentstest",
+		"object":  "chat.completion",
+		"created": 0,
+		"model":   model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"finish_reason": response.finishReason(),
+			"message":       message,
+		}},
+		"usage": map[string]any{"prompt_tokens": 0, "completion_tokens": 0, "total_tokens": 0},
+	}
+}
+
+// chunks returns the chunks of the streamed response: the content, the tool call deltas, then the finish reason.
+func (response Response) chunks(model string) []map[string]any {
+	chunk := func(delta map[string]any, finishReason any) map[string]any {
+		return map[string]any{
+			"id":      "chatcmpl-agentstest",
+			"object":  "chat.completion.chunk",
+			"created": 0,
+			"model":   model,
+			"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finishReason}},
+		}
+	}
+
+	chunks := []map[string]any{chunk(map[string]any{"role": "assistant", "content": ""}, nil)}
+	streamed := response.streamed
+	if streamed == nil && response.content != "" {
+		streamed = strings.SplitAfter(response.content, " ")
+	}
+	for _, content := range streamed {
+		chunks = append(chunks, chunk(map[string]any{"content": content}, nil))
+	}
+	for i, call := range response.toolCalls {
+		half := len(call.Arguments) / 2
+		chunks = append(chunks,
+			chunk(map[string]any{"tool_calls": []map[string]any{{
+				"index":    i,
+				"id":       response.toolCallID(i),
+				"type":     "function",
+				"function": map[string]any{"name": call.Name, "arguments": call.Arguments[:half]},
+			}}}, nil),
+			chunk(map[string]any{"tool_calls": []map[string]any{{
+				"index":    i,
+				"function": map[string]any{"arguments": call.Arguments[half:]},
+			}}}, nil),
+		)
+	}
+	return append(chunks, chunk(map[string]any{}, response.finishReason()))
+}
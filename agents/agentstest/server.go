@@ -0,0 +1,231 @@
+// Package agentstest provides an in-process OpenAI-compatible model server for the tests of the agents.
+//
+// The server answers the chat completions (streamed or not) with scripted responses, in order,
+// creates deterministic embeddings, and records the requests it receives:
+//
+//	model := agentstest.NewServer(t,
+//		agentstest.ToolCalls(agentstest.Call("add", `{"a":10,"b":32}`)),
+//		agentstest.Text("The result is 42"),
+//	)
+//	bob, _ := agents.NewAgent("Bob", agents.WithDMR(model.URL), agents.WithModel("test"))
+//	...
+//	request := model.ChatRequests()[1]
+//	if request.LastMessage().Role != "tool" { ... }
+//
+// The package does not import the agents package, so it can be used by its internal tests.
+package agentstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Server is an OpenAI-compatible model server (chat completions and embeddings) running in the process.
+// Use its URL as the base URL of the OpenAI client (e.g. agents.WithDMR(server.URL)).
+type Server struct {
+	*httptest.Server
+
+	t         testing.TB
+	mutex     sync.Mutex
+	responses []Response
+	fallback  *Response
+	embedding func(text string) []float64
+	requests  []Request
+	failures  []string
+}
+
+// NewServer starts a server answering the chat completions with the responses, in order.
+// The server is closed at the end of the test.
+// When there is no more response (and no fallback, see SetFallback), the request fails with a 500 status
+// and the test is marked as failed at its end.
+func NewServer(t testing.TB, responses ...Response) *Server {
+	t.Helper()
+	server := &Server{
+		t:         t,
+		responses: responses,
+		embedding: Embedding,
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	// NOTE: the cleanups run in reverse order: the failures are reported once the server is closed
+	t.Cleanup(server.reportFailures)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fail records a failure of the test. The handlers run in the goroutines of the HTTP server,
+// where the test must not be failed: the failures are reported by reportFailures at the end of the test.
+func (server *Server) fail(format string, args ...any) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.failures = append(server.failures, fmt.Sprintf(format, args...))
+}
+
+func (server *Server) reportFailures() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, failure := range server.failures {
+		server.t.Errorf("%s", failure)
+	}
+}
+
+// AddResponses adds scripted responses to the chat completions.
+func (server *Server) AddResponses(responses ...Response) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.responses = append(server.responses, responses...)
+}
+
+// SetFallback sets the response of the chat completions once the scripted responses are used.
+func (server *Server) SetFallback(response Response) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.fallback = &response
+}
+
+// SetEmbedding sets the function creating the embeddings (default: Embedding).
+func (server *Server) SetEmbedding(embedding func(text string) []float64) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.embedding = embedding
+}
+
+// Pending returns the number of scripted responses not used yet.
+func (server *Server) Pending() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return len(server.responses)
+}
+
+// Requests returns the requests received by the server, in order.
+func (server *Server) Requests() []Request {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]Request{}, server.requests...)
+}
+
+// ChatRequests returns the chat completion requests received by the server, in order.
+func (server *Server) ChatRequests() []Request {
+	return server.requestsOf(chatCompletionsPath)
+}
+
+// EmbeddingRequests returns the embedding requests received by the server, in order.
+func (server *Server) EmbeddingRequests() []Request {
+	return server.requestsOf(embeddingsPath)
+}
+
+// LastRequest returns the last request received by the server (a zero Request if there is none).
+func (server *Server) LastRequest() Request {
+	requests := server.Requests()
+	if len(requests) == 0 {
+		return Request{}
+	}
+	return requests[len(requests)-1]
+}
+
+func (server *Server) requestsOf(path string) []Request {
+	requests := []Request{}
+	for _, request := range server.Requests() {
+		if strings.HasSuffix(request.Path, path) {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+const (
+	chatCompletionsPath = "/chat/completions"
+	embeddingsPath      = "/embeddings"
+)
+
+func (server *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	request := newRequest(r, body)
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, request)
+	server.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, chatCompletionsPath):
+		server.handleChatCompletion(w, request)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, embeddingsPath):
+		server.handleEmbeddings(w, request)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("agentstest: unknown endpoint %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (server *Server) handleChatCompletion(w http.ResponseWriter, request Request) {
+	server.mutex.Lock()
+	var response Response
+	switch {
+	case len(server.responses) > 0:
+		response = server.responses[0]
+		server.responses = server.responses[1:]
+	case server.fallback != nil:
+		response = *server.fallback
+	default:
+		server.mutex.Unlock()
+		server.fail("agentstest: no more scripted responses for the chat completion request %d", len(server.ChatRequests()))
+		writeError(w, http.StatusInternalServerError, "agentstest: no more scripted responses")
+		return
+	}
+	server.mutex.Unlock()
+
+	if response.status != 0 {
+		writeError(w, response.status, response.message)
+		return
+	}
+	model := request.Model
+	if model == "" {
+		model = "agentstest"
+	}
+	if !request.Stream {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response.completion(model))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+	for _, chunk := range response.chunks(model) {
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func (server *Server) handleEmbeddings(w http.ResponseWriter, request Request) {
+	server.mutex.Lock()
+	embedding := server.embedding
+	server.mutex.Unlock()
+
+	data := []map[string]any{}
+	for i, input := range request.Inputs {
+		data = append(data, map[string]any{"object": "embedding", "index": i, "embedding": embedding(input)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"object": "list",
+		"model":  request.Model,
+		"data":   data,
+		"usage":  map[string]any{"prompt_tokens": 0, "total_tokens": 0},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"message": message, "type": "agentstest_error"},
+	})
+}
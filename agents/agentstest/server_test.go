@@ -0,0 +1,208 @@
+package agentstest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/budgies-nest/budgie/agents"
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// go test -v -run TestChatCompletion
+func TestChatCompletion(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.Text("Ham, pineapple and cheese"),
+		agentstest.Error(http.StatusBadRequest, "unknown model"),
+	)
+	bob, err := agents.NewAgent("Bob",
+		agents.WithDMR(model.URL),
+		agents.WithModel("ai/qwen2.5"),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.AddSystemMessage("You are a Hawaiian pizza expert")
+	bob.AddUserMessage("Give me the main ingredients of the Hawaiian pizza")
+
+	answer, err := bob.ChatCompletion(context.Background())
+	if err != nil || answer != "Ham, pineapple and cheese" {
+		t.Fatalf("😡 Unexpected answer: %q %v", answer, err)
+	}
+	request := model.LastRequest()
+	if request.Model != "ai/qwen2.5" || request.Stream || len(request.Messages) != 2 {
+		t.Errorf("😡 Unexpected request: %+v", request)
+	}
+	if message := request.LastMessage(); message.Role != "user" || !strings.Contains(message.Content, "Hawaiian pizza") {
+		t.Errorf("😡 Unexpected last message: %+v", message)
+	}
+
+	_, err = bob.ChatCompletion(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "unknown model") {
+		t.Errorf("😡 Expected the scripted error, got %v", err)
+	}
+
+	model.SetFallback(agentstest.Text("I don't know"))
+	answer, _ = bob.ChatCompletion(context.Background())
+	if answer != "I don't know" || model.Pending() != 0 || len(model.ChatRequests()) != 3 {
+		t.Errorf("😡 Expected the fallback answer, got %q", answer)
+	}
+}
+
+// go test -v -run TestRetryableError
+func TestRetryableError(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.Error(http.StatusTooManyRequests, "rate limit"),
+		agentstest.Text("Hello"),
+	)
+	// NOTE: without retries, the scripted error does not use the next responses
+	bob, err := agents.NewAgent("Bob",
+		agents.WithChatProvider(agents.NewOpenAIProvider(
+			option.WithBaseURL(model.URL),
+			option.WithAPIKey(""),
+			option.WithMaxRetries(0),
+		)),
+		agents.WithModel("ai/qwen2.5"),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	bob.AddUserMessage("Hello")
+
+	if _, err := bob.ChatCompletion(context.Background()); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("😡 Expected the scripted error, got %v", err)
+	}
+	if answer, err := bob.ChatCompletion(context.Background()); err != nil || answer != "Hello" {
+		t.Errorf("😡 Expected the next scripted answer: %q %v", answer, err)
+	}
+}
+
+// go test -v -run TestToolCallsAndStream
+func TestToolCallsAndStream(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("add", `{"a":10,"b":32}`)).WithContent("Let me compute. "),
+		agentstest.Stream("The result", " is 42"),
+	)
+	type addArgs struct {
+		A float64 `json:"a"`
+		B float64 `json:"b"`
+	}
+	bob, err := agents.NewAgent("Bob",
+		agents.WithDMR(model.URL),
+		agents.WithModel("ai/qwen2.5"),
+		agents.RegisterTool("add", "add two numbers", func(args addArgs) (float64, error) {
+			return args.A + args.B, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	chunks := []string{}
+	result, err := bob.RunStream(context.Background(), "Add 10 and 32", agents.RunOptions{}, func(self *agents.Agent, content string, err error) error {
+		chunks = append(chunks, content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("😡 Failed to run: %v", err)
+	}
+	if result.Answer != "The result is 42" || strings.Join(chunks, "|") != "Let |me |compute. |The result| is 42" {
+		t.Errorf("😡 Unexpected answer %q, chunks %q", result.Answer, chunks)
+	}
+	if len(result.Steps) != 1 || result.Steps[0].Results[0] != "42" {
+		t.Errorf("😡 Unexpected steps: %+v", result.Steps)
+	}
+
+	requests := model.ChatRequests()
+	if len(requests) != 2 || !requests[0].Stream || !requests[0].HasTool("add") {
+		t.Fatalf("😡 Unexpected requests: %+v", requests)
+	}
+	assistant := requests[1].MessagesWithRole("assistant")
+	if len(assistant) != 1 || assistant[0].ToolCalls[0].ID != "call_1" || assistant[0].ToolCalls[0].Arguments != `{"a":10,"b":32}` {
+		t.Errorf("😡 Expected the accumulated tool call, got %+v", assistant)
+	}
+	if tool := requests[1].LastMessage(); tool.Role != "tool" || tool.ToolCallID != "call_1" || tool.Content != "42" {
+		t.Errorf("😡 Unexpected tool message: %+v", tool)
+	}
+}
+
+// go test -v -run TestEmbeddings
+func TestEmbeddings(t *testing.T) {
+	model := agentstest.NewServer(t)
+	ctx := context.Background()
+	bob, err := agents.NewAgent("Bob",
+		agents.WithDMR(model.URL),
+		agents.WithEmbeddingParams(openai.EmbeddingNewParams{Model: "ai/mxbai-embed-large"}),
+		agents.WithRAGMemory(ctx, []string{
+			"John Steed is the English gentleman spy with a bowler hat and an umbrella",
+			"Emma Peel is a brilliant scientist and a martial arts expert",
+			"Mother is the wheelchair-bound superior of Steed",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	similarities, err := bob.RAGMemorySearchSimilaritiesWithText(ctx, "Who is Emma Peel?", 0.3)
+	if err != nil {
+		t.Fatalf("😡 Failed to search the RAG memory: %v", err)
+	}
+	if len(similarities) != 1 || !strings.HasPrefix(similarities[0], "Emma Peel") {
+		t.Errorf("😡 Expected the Emma Peel chunk, got %q", similarities)
+	}
+
+	requests := model.EmbeddingRequests()
+	if len(requests) != 4 || requests[3].Inputs[0] != "Who is Emma Peel?" || requests[0].Model != "ai/mxbai-embed-large" {
+		t.Errorf("😡 Unexpected embedding requests: %+v", requests)
+	}
+	if first, again := agentstest.Embedding("Emma Peel"), agentstest.Embedding("emma, peel!"); len(first) != agentstest.EmbeddingDimensions || first[0] != again[0] {
+		t.Errorf("😡 Expected deterministic embeddings")
+	}
+}
+
+// recordingT records the cleanups and the errors of a test, to check the failures reported by the server.
+type recordingT struct {
+	testing.TB
+	cleanups []func()
+	errors   []string
+}
+
+func (recorder *recordingT) Helper() {}
+
+func (recorder *recordingT) Cleanup(cleanup func()) {
+	recorder.cleanups = append(recorder.cleanups, cleanup)
+}
+
+func (recorder *recordingT) Errorf(format string, args ...any) {
+	recorder.errors = append(recorder.errors, fmt.Sprintf(format, args...))
+}
+
+// go test -v -run TestUnexpectedRequest
+func TestUnexpectedRequest(t *testing.T) {
+	recorder := &recordingT{TB: t}
+	model := agentstest.NewServer(recorder)
+
+	response, err := http.Post(model.URL+"/chat/completions", "application/json", strings.NewReader(`{"model":"test"}`))
+	if err != nil {
+		t.Fatalf("😡 Failed to call the server: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusInternalServerError {
+		t.Errorf("😡 Expected a 500 status, got %d", response.StatusCode)
+	}
+
+	// The failure is reported at the end of the test, not by the handler
+	if len(recorder.errors) != 0 {
+		t.Errorf("😡 Expected no failure during the test, got %q", recorder.errors)
+	}
+	for i := len(recorder.cleanups) - 1; i >= 0; i-- {
+		recorder.cleanups[i]()
+	}
+	if len(recorder.errors) != 1 || !strings.Contains(recorder.errors[0], "no more scripted responses") {
+		t.Errorf("😡 Expected the failure to be reported, got %q", recorder.errors)
+	}
+}
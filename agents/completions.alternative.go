@@ -34,6 +34,7 @@ func (agent *Agent) AlternativeToolsCompletion(ctx context.Context) ([]openai.Ch
 	You have access to the following tools:
 	`
 
+	agent.refreshChangedMCPTools(ctx)
 	catalog := agent.Params.Tools
 
 	toolsJson, err := json.Marshal(catalog)
@@ -148,7 +149,7 @@ func (agent *Agent) AlternativeToolsCompletion(ctx context.Context) ([]openai.Ch
 
 	agent.Params.ResponseFormat = responseFormat
 
-	completion, err := agent.clientEngine.Chat.Completions.New(ctx, agent.Params)
+	completion, err := agent.chatProvider.ChatCompletion(ctx, agent.Params)
 	if err != nil {
 		agent.Params.Tools = catalog // Restore the tools in case of error
 		duration := time.Since(start)
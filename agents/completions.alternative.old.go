@@ -127,7 +127,7 @@ func (agent *Agent) OldAlternativeToolsCompletion(ctx context.Context) ([]openai
 	agent.Params.Tools = nil
 	// IMPORTANT: at the end of the function, we will restore the tools to the original state with the catalog variable
 
-	completion, err := agent.clientEngine.Chat.Completions.New(ctx, agent.Params)
+	completion, err := agent.chatProvider.ChatCompletion(ctx, agent.Params)
 	if err != nil {
 		agent.Params.Tools = catalog // Restore the tools in case of error
 		duration := time.Since(start)
@@ -219,7 +219,7 @@ func (agent *Agent) OldAlternativeToolsCompletion(ctx context.Context) ([]openai
 
 	agent.Params.ResponseFormat = responseFormat
 
-	completionNext, err := agent.clientEngine.Chat.Completions.New(ctx, agent.Params)
+	completionNext, err := agent.chatProvider.ChatCompletion(ctx, agent.Params)
 
 	if err != nil {
 		agent.Params.Tools = catalog // Restore the tools in case of error
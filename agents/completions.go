@@ -12,6 +12,30 @@ import (
 // It sends the parameters set in the Agent and returns the response content or an error.
 // It is a synchronous operation that waits for the completion to finish.
 func (agent *Agent) ChatCompletion(ctx context.Context) (string, error) {
+	return agent.chatCompletion(ctx, completionOptions{})
+}
+
+// completionOptions configures the completions sent by the tool calling loop (see run):
+// the Agent's fields are never changed to configure a completion, an agent can be used concurrently
+// (e.g. a completion of the HTTP server during a Run).
+type completionOptions struct {
+	skipMemory   bool // The conversation memory was applied once, at the start of the Run
+	withoutTools bool // The final answer of the Run is requested without the tools
+}
+
+// completionParams applies the conversation memory (unless skipped) and returns the parameters of the completion.
+func (agent *Agent) completionParams(ctx context.Context, options completionOptions) openai.ChatCompletionNewParams {
+	if !options.skipMemory {
+		agent.applyConversationMemory(ctx)
+	}
+	params := agent.Params
+	if options.withoutTools {
+		params.Tools = nil
+	}
+	return params
+}
+
+func (agent *Agent) chatCompletion(ctx context.Context, options completionOptions) (string, error) {
 	start := time.Now()
 
 	// Create context for handlers
@@ -28,7 +52,9 @@ func (agent *Agent) ChatCompletion(ctx context.Context) (string, error) {
 		handler(handlerCtx)
 	}
 
-	completion, err := agent.clientEngine.Chat.Completions.New(ctx, agent.Params)
+	params := agent.completionParams(ctx, options)
+
+	completion, err := agent.chatProvider.ChatCompletion(ctx, params)
 	duration := time.Since(start)
 
 	var response string
@@ -52,7 +78,7 @@ func (agent *Agent) ChatCompletion(ctx context.Context) (string, error) {
 		handler(handlerCtx)
 	}
 
-	agent.logger.LogChatCompletion(agent.Name, agent.Params, response, duration, finalErr)
+	agent.logger.LogChatCompletion(agent.Name, params, response, duration, finalErr)
 
 	if finalErr != nil {
 		return "", finalErr
@@ -65,9 +91,18 @@ func (agent *Agent) ChatCompletion(ctx context.Context) (string, error) {
 // The callback function receives the Agent instance, the content of the chunk, and any error that occurred.
 // It returns the accumulated response content and any error that occurred during the streaming process.
 // The callback function should return an error if it wants to stop the streaming process.
+// The tool calls streamed by the model are ignored, see ChatCompletionStreamWithTools.
 func (agent *Agent) ChatCompletionStream(ctx context.Context, callBack func(self *Agent, content string, err error) error) (string, error) {
+	result, err := agent.streamCompletion(ctx, callBack, completionOptions{})
+	return result.Content, err
+}
+
+// streamCompletion streams the completion: the content chunks are sent to the callback,
+// the tool call deltas are accumulated into complete tool calls.
+func (agent *Agent) streamCompletion(ctx context.Context, callBack func(self *Agent, content string, err error) error, options completionOptions) (StreamResult, error) {
 	start := time.Now()
-	response := ""
+	result := StreamResult{}
+	toolCalls := toolCallAccumulator{}
 
 	// Create context for handlers
 	handlerCtx := &ChatCompletionStreamContext{
@@ -84,49 +119,46 @@ func (agent *Agent) ChatCompletionStream(ctx context.Context, callBack func(self
 		handler(handlerCtx)
 	}
 
-	stream := agent.clientEngine.Chat.Completions.NewStreaming(ctx, agent.Params)
-	var cbkRes error
+	params := agent.completionParams(ctx, options)
 
-	for stream.Next() {
-		chunk := stream.Current()
-		// Stream each chunk as it arrives
-		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
-			cbkRes = callBack(agent, chunk.Choices[0].Delta.Content, nil)
-			response += chunk.Choices[0].Delta.Content
+	finalErr := agent.chatProvider.ChatCompletionStream(ctx, params, func(chunk openai.ChatCompletionChunk) error {
+		if len(chunk.Choices) == 0 {
+			return nil
 		}
-
-		if cbkRes != nil {
-			break
+		choice := chunk.Choices[0]
+		toolCalls.add(choice.Delta.ToolCalls)
+		if choice.FinishReason != "" {
+			result.FinishReason = choice.FinishReason
 		}
-	}
-
+		// Stream each chunk as it arrives
+		if choice.Delta.Content != "" {
+			result.Content += choice.Delta.Content
+			return callBack(agent, choice.Delta.Content, nil)
+		}
+		return nil
+	})
 	duration := time.Since(start)
-	var finalErr error
-
-	if cbkRes != nil {
-		finalErr = cbkRes
-	} else if err := stream.Err(); err != nil {
-		finalErr = err
-	} else if err := stream.Close(); err != nil {
-		finalErr = err
-	}
+	result.ToolCalls = toolCalls.toolCalls
 
 	// Update handler context with results
 	handlerCtx.Duration = duration
 	handlerCtx.Error = finalErr
-	handlerCtx.Response = &response
+	handlerCtx.Response = &result.Content
 
 	// Call after handlers
 	for _, handler := range agent.completionHandlers.AfterChatCompletionStream {
 		handler(handlerCtx)
 	}
 
-	agent.logger.LogChatCompletionStream(agent.Name, agent.Params, response, duration, finalErr)
+	agent.logger.LogChatCompletionStream(agent.Name, params, result.Content, duration, finalErr)
+	if len(result.ToolCalls) > 0 {
+		agent.logger.LogToolsCompletion(agent.Name, params, result.ToolCalls, duration, finalErr)
+	}
 
 	if finalErr != nil {
-		return response, finalErr
+		return result, finalErr
 	}
-	return response, nil
+	return result, nil
 }
 
 // ToolsCompletion handles the tool calls completion request using the DMR client.
@@ -149,7 +181,10 @@ func (agent *Agent) ToolsCompletion(ctx context.Context) ([]openai.ChatCompletio
 		handler(handlerCtx)
 	}
 
-	completion, err := agent.clientEngine.Chat.Completions.New(ctx, agent.Params)
+	agent.refreshChangedMCPTools(ctx)
+	agent.applyConversationMemory(ctx)
+
+	completion, err := agent.chatProvider.ChatCompletion(ctx, agent.Params)
 	duration := time.Since(start)
 
 	var detectedToolCalls []openai.ChatCompletionMessageToolCall
@@ -0,0 +1,79 @@
+package agents
+
+import (
+	"context"
+
+	"github.com/openai/openai-go"
+)
+
+// StreamResult is the result of a streamed completion: the content and the tool calls of the model.
+type StreamResult struct {
+	Content      string
+	ToolCalls    []openai.ChatCompletionMessageToolCall
+	FinishReason string // e.g. "stop" or "tool_calls"
+}
+
+// Message returns the assistant message of the streamed completion, with its tool calls.
+// Use Message().ToParam() to add it to the messages of the agent before the tool messages.
+func (result StreamResult) Message() openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{
+		Role:      "assistant",
+		Content:   result.Content,
+		ToolCalls: result.ToolCalls,
+	}
+}
+
+// ChatCompletionStreamWithTools streams the completion with the tools of the agent.
+// The content chunks are sent to the callback as they arrive (like ChatCompletionStream),
+// and the tool call deltas are accumulated into complete tool calls, returned in the StreamResult.
+// So a streaming agent can use the tools without a separate ToolsCompletion, see RunStream.
+func (agent *Agent) ChatCompletionStreamWithTools(ctx context.Context, callBack func(self *Agent, content string, err error) error) (StreamResult, error) {
+	agent.refreshChangedMCPTools(ctx)
+	return agent.streamCompletion(ctx, callBack, completionOptions{})
+}
+
+// RunStream is the streaming version of Run: the content of the completions is sent to the callback as it arrives,
+// and the tool calls streamed by the model are executed until the model answers without tool calls.
+// The assistant and tool messages are appended to the Agent's messages, including the final answer.
+func (agent *Agent) RunStream(ctx context.Context, userInput string, opts RunOptions, callBack func(self *Agent, content string, err error) error) (RunResult, error) {
+	return agent.run(ctx, userInput, opts,
+		func(ctx context.Context) (openai.ChatCompletionMessage, error) {
+			agent.refreshChangedMCPTools(ctx)
+			result, err := agent.streamCompletion(ctx, callBack, completionOptions{skipMemory: true})
+			return result.Message(), err
+		},
+		func(ctx context.Context) (string, error) {
+			result, err := agent.streamCompletion(ctx, callBack, completionOptions{skipMemory: true, withoutTools: true})
+			return result.Content, err
+		},
+	)
+}
+
+// toolCallAccumulator merges the streamed tool call deltas into complete tool calls:
+// the deltas with the same index belong to the same tool call, the name and the arguments are sent in pieces.
+// The tool calls are keyed by their index (the index sent by the model is not used as a slice position,
+// so a sparse or huge index does not allocate empty tool calls) and kept in the order of their first delta.
+type toolCallAccumulator struct {
+	toolCalls []openai.ChatCompletionMessageToolCall
+	positions map[int64]int
+}
+
+func (accumulator *toolCallAccumulator) add(deltas []openai.ChatCompletionChunkChoiceDeltaToolCall) {
+	for _, delta := range deltas {
+		position, ok := accumulator.positions[delta.Index]
+		if !ok {
+			if accumulator.positions == nil {
+				accumulator.positions = make(map[int64]int)
+			}
+			position = len(accumulator.toolCalls)
+			accumulator.positions[delta.Index] = position
+			accumulator.toolCalls = append(accumulator.toolCalls, openai.ChatCompletionMessageToolCall{Type: "function"})
+		}
+		toolCall := &accumulator.toolCalls[position]
+		if delta.ID != "" {
+			toolCall.ID = delta.ID
+		}
+		toolCall.Function.Name += delta.Function.Name
+		toolCall.Function.Arguments += delta.Function.Arguments
+	}
+}
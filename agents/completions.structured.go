@@ -0,0 +1,163 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/budgies-nest/budgie/helpers"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+// DefaultStructuredCompletionMaxRetries is the default number of retries of StructuredCompletion.
+const DefaultStructuredCompletionMaxRetries = 2
+
+// ErrInvalidStructuredOutput is returned by StructuredCompletion when the model never answers with a valid JSON object.
+var ErrInvalidStructuredOutput = errors.New("invalid structured output")
+
+// StructuredCompletionOptions configures StructuredCompletion.
+type StructuredCompletionOptions struct {
+	// Name of the schema sent to the model (default: the name of the Go type)
+	Name string
+	// Description of the schema sent to the model
+	Description string
+	// MaxRetries is the number of retries when the answer does not match the schema
+	// (default: DefaultStructuredCompletionMaxRetries, negative: no retry)
+	MaxRetries int
+	// Strict enables the strict mode of the structured outputs: the objects do not accept other properties
+	Strict bool
+}
+
+// StructuredCompletion runs a chat completion whose answer is a JSON object matching the JSON schema of T,
+// and returns it decoded into T.
+// The schema is generated from T (see helpers.GenerateJSONSchema for the struct tags) and sent as the ResponseFormat.
+// When the answer is not valid JSON or does not match the schema, the validation errors are sent back to the model,
+// which answers again (MaxRetries times).
+// The tools of the agent are not sent, and the Agent's messages and parameters are not modified.
+//
+// Example:
+//
+//	type Character struct {
+//		Name    string `json:"name" description:"The name of the character"`
+//		Species string `json:"species" enum:"human,vulcan,klingon"`
+//	}
+//
+//	character, err := agents.StructuredCompletion[Character](ctx, bob)
+func StructuredCompletion[T any](ctx context.Context, agent *Agent, options ...StructuredCompletionOptions) (T, error) {
+	var result T
+	var opts StructuredCompletionOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultStructuredCompletionMaxRetries
+	}
+
+	schema := helpers.JSONSchemaOf[T]()
+	if schema["type"] != "object" {
+		return result, fmt.Errorf("the structured output must be a JSON object (struct or map), got %s", reflect.TypeFor[T]())
+	}
+	if opts.Strict {
+		var err error
+		if schema, err = helpers.StrictJSONSchema(schema); err != nil {
+			return result, err
+		}
+	}
+	if opts.Name == "" {
+		opts.Name = schemaName(reflect.TypeFor[T]().Name())
+	}
+
+	structuredAgent := agent.withMessages(slices.Clone(agent.Params.Messages))
+	structuredAgent.Params.Tools = nil
+	structuredAgent.Params.ResponseFormat = jsonSchemaResponseFormat(opts.Name, opts.Description, schema, opts.Strict)
+
+	var errs []string
+	for attempt := 0; attempt <= max(opts.MaxRetries, 0); attempt++ {
+		answer, err := structuredAgent.ChatCompletion(ctx)
+		if err != nil {
+			return result, err
+		}
+
+		errs = decodeStructuredOutput(answer, schema, &result)
+		if len(errs) == 0 {
+			return result, nil
+		}
+
+		agent.logger.LogError(agent.Name, "structured_completion", "Invalid structured output", ErrInvalidStructuredOutput, map[string]any{
+			"attempt": attempt + 1,
+			"errors":  errs,
+		})
+		structuredAgent.Params.Messages = append(structuredAgent.Params.Messages,
+			openai.AssistantMessage(answer),
+			openai.UserMessage("Your answer does not match the JSON schema:\n- "+strings.Join(errs, "\n- ")+
+				"\nAnswer again with only a JSON object matching the schema."),
+		)
+	}
+	return result, fmt.Errorf("%w: %s", ErrInvalidStructuredOutput, strings.Join(errs, "; "))
+}
+
+// decodeStructuredOutput validates the answer against the schema and decodes it into target.
+// It returns the validation errors.
+func decodeStructuredOutput(answer string, schema map[string]any, target any) []string {
+	answer = trimJSONCodeFence(answer)
+
+	var value any
+	if err := json.Unmarshal([]byte(answer), &value); err != nil {
+		return []string{"the answer is not valid JSON: " + err.Error()}
+	}
+	if errs := helpers.ValidateJSONSchema(schema, value); len(errs) > 0 {
+		return errs
+	}
+	if err := json.Unmarshal([]byte(answer), target); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+var jsonCodeFence = regexp.MustCompile("(?s)^```[a-zA-Z]*\\s*(.*?)\\s*```$")
+
+// trimJSONCodeFence removes the markdown code fence around a JSON answer (```json ... ```), added by some models.
+func trimJSONCodeFence(answer string) string {
+	answer = strings.TrimSpace(answer)
+	if matches := jsonCodeFence.FindStringSubmatch(answer); matches != nil {
+		return matches[1]
+	}
+	return answer
+}
+
+var schemaNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// schemaName returns a valid name for the response format (letters, digits, '_' and '-').
+func schemaName(name string) string {
+	name = schemaNameInvalidChars.ReplaceAllString(name, "_")
+	if name == "" {
+		return "response"
+	}
+	return name
+}
+
+// jsonSchemaResponseFormat returns the response format asking the model to answer with a JSON value matching the schema.
+func jsonSchemaResponseFormat(name string, description string, schema map[string]any, strict bool) openai.ChatCompletionNewParamsResponseFormatUnion {
+	jsonSchema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+		Name:   name,
+		Schema: schema,
+	}
+	if description != "" {
+		jsonSchema.Description = openai.String(description)
+	}
+	if strict {
+		jsonSchema.Strict = openai.Bool(true)
+	}
+	return openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+			Type:       "json_schema",
+			JSONSchema: jsonSchema,
+		},
+	}
+}
@@ -0,0 +1,304 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/budgies-nest/budgie/helpers"
+	"github.com/openai/openai-go"
+)
+
+// ConversationMemory reduces the conversation (the Agent's messages) before each completion
+// (once at the start of Run and RunStream).
+// The system messages must be kept: they hold the instructions of the agent.
+// The order of the messages must be kept, and the last user message too: it is the question to answer.
+type ConversationMemory interface {
+	Trim(ctx context.Context, agent *Agent, messages []openai.ChatCompletionMessageParamUnion) ([]openai.ChatCompletionMessageParamUnion, error)
+}
+
+// applyConversationMemory trims the Agent's messages with the conversation memory, if any.
+// If the memory fails, the messages are kept as is and the error is logged.
+func (agent *Agent) applyConversationMemory(ctx context.Context) {
+	if agent.conversationMemory == nil {
+		return
+	}
+	messages, err := agent.conversationMemory.Trim(ctx, agent, agent.Params.Messages)
+	if err != nil {
+		agent.logger.LogError(agent.Name, "conversation_memory", "Failed to trim the conversation", err, nil)
+		return
+	}
+	agent.Params.Messages = messages
+}
+
+// ErrInvalidConversationMemory is returned when a conversation memory is created with invalid sizes.
+var ErrInvalidConversationMemory = errors.New("invalid conversation memory")
+
+// --- Window memory ---
+
+// WindowMemory keeps the system messages and the last MaxMessages other messages.
+// The last user message and the messages after it (the current turn) are always kept.
+type WindowMemory struct {
+	MaxMessages int
+}
+
+// NewWindowMemory creates a conversation memory keeping the system messages and the last maxMessages other messages.
+// maxMessages must be positive.
+func NewWindowMemory(maxMessages int) (*WindowMemory, error) {
+	if maxMessages <= 0 {
+		return nil, fmt.Errorf("%w: the maximum number of messages must be positive, got %d", ErrInvalidConversationMemory, maxMessages)
+	}
+	return &WindowMemory{MaxMessages: maxMessages}, nil
+}
+
+func (memory *WindowMemory) Trim(ctx context.Context, agent *Agent, messages []openai.ChatCompletionMessageParamUnion) ([]openai.ChatCompletionMessageParamUnion, error) {
+	if memory.MaxMessages <= 0 {
+		return nil, fmt.Errorf("%w: the maximum number of messages must be positive, got %d", ErrInvalidConversationMemory, memory.MaxMessages)
+	}
+	conversation := conversationIndexes(messages)
+	return keepConversationFrom(messages, conversation, len(conversation)-memory.MaxMessages), nil
+}
+
+// --- Token budget memory ---
+
+// TokenBudgetMemory keeps the system messages and the most recent messages fitting in MaxTokens.
+// The tokens are estimated with EstimateTokens.
+// The last user message and the messages after it (the current turn) are always kept, even beyond the budget.
+type TokenBudgetMemory struct {
+	MaxTokens int
+}
+
+// NewTokenBudgetMemory creates a conversation memory keeping the system messages
+// and the most recent messages fitting in maxTokens (system messages included). maxTokens must be positive.
+func NewTokenBudgetMemory(maxTokens int) (*TokenBudgetMemory, error) {
+	if maxTokens <= 0 {
+		return nil, fmt.Errorf("%w: the token budget must be positive, got %d", ErrInvalidConversationMemory, maxTokens)
+	}
+	return &TokenBudgetMemory{MaxTokens: maxTokens}, nil
+}
+
+func (memory *TokenBudgetMemory) Trim(ctx context.Context, agent *Agent, messages []openai.ChatCompletionMessageParamUnion) ([]openai.ChatCompletionMessageParamUnion, error) {
+	if memory.MaxTokens <= 0 {
+		return nil, fmt.Errorf("%w: the token budget must be positive, got %d", ErrInvalidConversationMemory, memory.MaxTokens)
+	}
+	conversation := conversationIndexes(messages)
+
+	budget := memory.MaxTokens
+	for _, message := range messages {
+		if isSystemMessage(message) {
+			budget -= EstimateTokens(message)
+		}
+	}
+
+	start := len(conversation)
+	for start > 0 {
+		tokens := EstimateTokens(messages[conversation[start-1]])
+		if tokens > budget {
+			break
+		}
+		budget -= tokens
+		start--
+	}
+	return keepConversationFrom(messages, conversation, start), nil
+}
+
+// EstimateTokens estimates the number of tokens of a message (about 4 characters per token).
+func EstimateTokens(message openai.ChatCompletionMessageParamUnion) int {
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return 0
+	}
+	return len(jsonData)/4 + 1
+}
+
+// --- Summary memory ---
+
+// summaryPrefix identifies the system message holding the summary of the conversation.
+const summaryPrefix = "SUMMARY OF THE PREVIOUS CONVERSATION:\n"
+
+// DefaultSummaryInstructions are the instructions used by SummaryMemory to summarize the conversation.
+const DefaultSummaryInstructions = `You are summarizing a conversation between a user and an AI assistant.
+Write a concise summary keeping the facts, the decisions, the names and the open questions.
+Do not add anything that is not in the conversation.`
+
+// SummaryMemory replaces the oldest messages with a summary generated by the agent's own model
+// when the conversation exceeds MaxMessages (system messages excluded).
+// The last KeepLast messages are kept as is (and always the last user message and the messages after it),
+// and the summary is stored as a system message in place of the summarized messages.
+// The previous summary is included in the next one.
+type SummaryMemory struct {
+	MaxMessages  int
+	KeepLast     int
+	Instructions string // Default: DefaultSummaryInstructions
+}
+
+// NewSummaryMemory creates a conversation memory summarizing the oldest messages
+// when the conversation exceeds maxMessages, and keeping the last keepLast messages.
+// maxMessages must be positive, and keepLast between 0 and maxMessages-1.
+func NewSummaryMemory(maxMessages int, keepLast int) (*SummaryMemory, error) {
+	if maxMessages <= 0 {
+		return nil, fmt.Errorf("%w: the maximum number of messages must be positive, got %d", ErrInvalidConversationMemory, maxMessages)
+	}
+	if keepLast < 0 || keepLast >= maxMessages {
+		return nil, fmt.Errorf("%w: the number of kept messages must be between 0 and %d, got %d", ErrInvalidConversationMemory, maxMessages-1, keepLast)
+	}
+	return &SummaryMemory{
+		MaxMessages: maxMessages,
+		KeepLast:    keepLast,
+	}, nil
+}
+
+func (memory *SummaryMemory) Trim(ctx context.Context, agent *Agent, messages []openai.ChatCompletionMessageParamUnion) ([]openai.ChatCompletionMessageParamUnion, error) {
+	if memory.MaxMessages <= 0 {
+		return nil, fmt.Errorf("%w: the maximum number of messages must be positive, got %d", ErrInvalidConversationMemory, memory.MaxMessages)
+	}
+	conversation := conversationIndexes(messages)
+	if len(conversation) <= memory.MaxMessages {
+		return messages, nil
+	}
+
+	// NOTE: the orphan tool messages are summarized with their assistant message
+	start := conversationStart(messages, conversation, len(conversation)-max(memory.KeepLast, 0))
+	if start == 0 {
+		return messages, nil
+	}
+	summarized := map[int]bool{}
+	toSummarize := []openai.ChatCompletionMessageParamUnion{}
+	for _, index := range conversation[:start] {
+		summarized[index] = true
+		toSummarize = append(toSummarize, messages[index])
+	}
+
+	// Extract the previous summary from the system messages
+	previousSummary := ""
+	for i, message := range messages {
+		if content := messageContent(message); isSystemMessage(message) && strings.HasPrefix(content, summaryPrefix) {
+			previousSummary = strings.TrimPrefix(content, summaryPrefix)
+			summarized[i] = true
+		}
+	}
+
+	summary, err := memory.summarize(ctx, agent, previousSummary, toSummarize)
+	if err != nil {
+		return nil, err
+	}
+
+	// The summary takes the place of the first summarized message, the order of the other messages is kept
+	result := []openai.ChatCompletionMessageParamUnion{}
+	for i, message := range messages {
+		if i == conversation[0] {
+			result = append(result, openai.SystemMessage(summaryPrefix+summary))
+		}
+		if !summarized[i] {
+			result = append(result, message)
+		}
+	}
+	return result, nil
+}
+
+func (memory *SummaryMemory) summarize(ctx context.Context, agent *Agent, previousSummary string, messages []openai.ChatCompletionMessageParamUnion) (string, error) {
+	transcript := strings.Builder{}
+	if previousSummary != "" {
+		transcript.WriteString("Previous summary:\n" + previousSummary + "\n\nConversation:\n")
+	}
+	for _, message := range messages {
+		content := messageContent(message)
+		if content == "" {
+			continue
+		}
+		transcript.WriteString(messageRole(message) + ": " + content + "\n")
+	}
+
+	instructions := memory.Instructions
+	if instructions == "" {
+		instructions = DefaultSummaryInstructions
+	}
+
+	// Use the agent's model without tools and without the conversation
+	params := agent.Params
+	params.Tools = nil
+	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{}
+	params.Messages = []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage(instructions),
+		openai.UserMessage(transcript.String()),
+	}
+
+	completion, err := agent.chatProvider.ChatCompletion(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("no choices found")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// --- Helpers ---
+
+// isSystemMessage returns true for the system (and developer) messages: the memories always keep them.
+func isSystemMessage(message openai.ChatCompletionMessageParamUnion) bool {
+	return message.OfSystem != nil || message.OfDeveloper != nil
+}
+
+// conversationIndexes returns the indexes of the messages that are not system messages.
+func conversationIndexes(messages []openai.ChatCompletionMessageParamUnion) []int {
+	conversation := []int{}
+	for i, message := range messages {
+		if !isSystemMessage(message) {
+			conversation = append(conversation, i)
+		}
+	}
+	return conversation
+}
+
+// conversationStart adjusts the first kept message of the conversation (a position in conversation):
+// the last user message and the messages after it are always kept,
+// and the leading tool messages whose assistant message (with the tool calls) is not kept are skipped.
+func conversationStart(messages []openai.ChatCompletionMessageParamUnion, conversation []int, start int) int {
+	start = max(start, 0)
+	for i := len(conversation) - 1; i >= 0; i-- {
+		if messages[conversation[i]].OfUser != nil {
+			start = min(start, i)
+			break
+		}
+	}
+	for start < len(conversation) && messages[conversation[start]].OfTool != nil {
+		start++
+	}
+	return start
+}
+
+// keepConversationFrom keeps the system messages and the conversation from start (see conversationStart),
+// in the order of the messages.
+func keepConversationFrom(messages []openai.ChatCompletionMessageParamUnion, conversation []int, start int) []openai.ChatCompletionMessageParamUnion {
+	start = conversationStart(messages, conversation, start)
+	first := len(messages)
+	if start < len(conversation) {
+		first = conversation[start]
+	}
+	kept := []openai.ChatCompletionMessageParamUnion{}
+	for i, message := range messages {
+		if i >= first || isSystemMessage(message) {
+			kept = append(kept, message)
+		}
+	}
+	return kept
+}
+
+func messageContent(message openai.ChatCompletionMessageParamUnion) string {
+	msgMap, err := helpers.MessageToMap(message)
+	if err != nil {
+		return ""
+	}
+	return msgMap["content"]
+}
+
+func messageRole(message openai.ChatCompletionMessageParamUnion) string {
+	msgMap, err := helpers.MessageToMap(message)
+	if err != nil {
+		return ""
+	}
+	return msgMap["role"]
+}
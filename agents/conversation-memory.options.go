@@ -0,0 +1,15 @@
+package agents
+
+// WithConversationMemory sets the strategy reducing the Agent's messages before each completion:
+// NewWindowMemory (last N messages), NewTokenBudgetMemory (token budget) or NewSummaryMemory (rolling summary).
+// The system messages are always kept, and Run applies the memory once, before the tool calling loop.
+// Example:
+//
+//	memory, err := agents.NewWindowMemory(10)
+//	...
+//	agents.WithConversationMemory(memory)
+func WithConversationMemory(memory ConversationMemory) AgentOption {
+	return func(agent *Agent) {
+		agent.conversationMemory = memory
+	}
+}
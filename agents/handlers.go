@@ -46,6 +46,18 @@ type AgentCallbackContext struct {
 	CompletionContext
 	TaskRequest  *TaskRequest  // Pointer to allow modification
 	TaskResponse *TaskResponse // Pointer to allow modification
+
+	streamContent func(content string) error
+}
+
+// StreamContent sends a chunk of the answer to the client of a message/stream request
+// (as an artifact update event). It does nothing for a message/send request.
+// It returns an error when the task is cancelled.
+func (ctx *AgentCallbackContext) StreamContent(content string) error {
+	if ctx.streamContent == nil {
+		return nil
+	}
+	return ctx.streamContent(content)
 }
 
 // Handler types for before and after completion events
@@ -3,8 +3,10 @@ package agents
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/openai/openai-go"
@@ -19,10 +21,15 @@ const (
 	LogLevelDebug
 )
 
+// Logger writes the logs of the agents as JSON lines.
+// It is safe for concurrent use: the level and the state can change while the agents log (e.g. EnableLogging).
 type Logger struct {
+	mutex   sync.RWMutex
 	level   LogLevel
 	logger  *log.Logger
 	enabled bool
+	// stdioServers is the number of running MCP stdio servers: stdout is reserved for the MCP protocol
+	stdioServers int
 }
 
 type LogEntry struct {
@@ -44,19 +51,62 @@ func NewLogger(level LogLevel, enabled bool) *Logger {
 }
 
 func (l *Logger) SetEnabled(enabled bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	l.enabled = enabled
 }
 
 func (l *Logger) IsEnabled() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
 	return l.enabled
 }
 
 func (l *Logger) SetLevel(level LogLevel) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	l.level = level
 }
 
+// allows returns true if the logger is enabled with at least the given level.
+func (l *Logger) allows(level LogLevel) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.enabled && l.level >= level
+}
+
+// SetOutput sets the destination of the logs (default: os.Stdout).
+func (l *Logger) SetOutput(w io.Writer) {
+	l.logger.SetOutput(w)
+}
+
+// reserveStdout writes the logs to stderr instead of stdout (e.g. while an MCP stdio server is running)
+// and returns the function releasing stdout.
+func (l *Logger) reserveStdout() func() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.stdioServers++
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.stdioServers--
+	}
+}
+
+// println writes a log line, to stderr when stdout is reserved (see reserveStdout).
+func (l *Logger) println(line string) {
+	l.mutex.RLock()
+	stdoutReserved := l.stdioServers > 0
+	l.mutex.RUnlock()
+	if stdoutReserved && l.logger.Writer() == os.Stdout {
+		log.New(os.Stderr, l.logger.Prefix(), l.logger.Flags()).Println(line)
+		return
+	}
+	l.logger.Println(line)
+}
+
 func (l *Logger) logEntry(entry LogEntry) {
-	if !l.enabled || l.level == LogLevelOff {
+	if !l.allows(LogLevelError) {
 		return
 	}
 
@@ -64,11 +114,11 @@ func (l *Logger) logEntry(entry LogEntry) {
 	if err != nil {
 		return
 	}
-	l.logger.Println(string(jsonData))
+	l.println(string(jsonData))
 }
 
 func (l *Logger) LogChatCompletion(agentName string, request openai.ChatCompletionNewParams, response string, duration time.Duration, err error) {
-	if !l.enabled || l.level < LogLevelInfo {
+	if !l.allows(LogLevelInfo) {
 		return
 	}
 
@@ -98,7 +148,7 @@ func (l *Logger) LogChatCompletion(agentName string, request openai.ChatCompleti
 }
 
 func (l *Logger) LogChatCompletionStream(agentName string, request openai.ChatCompletionNewParams, response string, duration time.Duration, err error) {
-	if !l.enabled || l.level < LogLevelInfo {
+	if !l.allows(LogLevelInfo) {
 		return
 	}
 
@@ -128,7 +178,7 @@ func (l *Logger) LogChatCompletionStream(agentName string, request openai.ChatCo
 }
 
 func (l *Logger) LogToolsCompletion(agentName string, request openai.ChatCompletionNewParams, toolCalls []openai.ChatCompletionMessageToolCall, duration time.Duration, err error) {
-	if !l.enabled || l.level < LogLevelInfo {
+	if !l.allows(LogLevelInfo) {
 		return
 	}
 
@@ -151,7 +201,7 @@ func (l *Logger) LogToolsCompletion(agentName string, request openai.ChatComplet
 		entry.Error = err.Error()
 	} else {
 		entry.Message = fmt.Sprintf("Tools completion successful with %d tool calls", len(toolCalls))
-		if l.level >= LogLevelDebug {
+		if l.allows(LogLevelDebug) {
 			toolNames := make([]string, len(toolCalls))
 			for i, tc := range toolCalls {
 				toolNames[i] = tc.Function.Name
@@ -164,7 +214,7 @@ func (l *Logger) LogToolsCompletion(agentName string, request openai.ChatComplet
 }
 
 func (l *Logger) LogToolExecution(agentName string, toolName string, args map[string]any, response string, duration time.Duration, err error) {
-	if !l.enabled || l.level < LogLevelInfo {
+	if !l.allows(LogLevelInfo) {
 		return
 	}
 
@@ -180,7 +230,7 @@ func (l *Logger) LogToolExecution(agentName string, toolName string, args map[st
 		},
 	}
 
-	if l.level >= LogLevelDebug {
+	if l.allows(LogLevelDebug) {
 		entry.Data["args"] = args
 		entry.Data["response"] = response
 	}
@@ -196,7 +246,7 @@ func (l *Logger) LogToolExecution(agentName string, toolName string, args map[st
 }
 
 func (l *Logger) LogMCPToolExecution(agentName string, toolName string, args map[string]any, response string, clientType string, duration time.Duration, err error) {
-	if !l.enabled || l.level < LogLevelInfo {
+	if !l.allows(LogLevelInfo) {
 		return
 	}
 
@@ -213,7 +263,7 @@ func (l *Logger) LogMCPToolExecution(agentName string, toolName string, args map
 		},
 	}
 
-	if l.level >= LogLevelDebug {
+	if l.allows(LogLevelDebug) {
 		entry.Data["args"] = args
 		entry.Data["response"] = response
 	}
@@ -229,7 +279,7 @@ func (l *Logger) LogMCPToolExecution(agentName string, toolName string, args map
 }
 
 func (l *Logger) LogAlternativeToolsCompletion(agentName string, request openai.ChatCompletionNewParams, toolCalls []openai.ChatCompletionMessageToolCall, duration time.Duration, err error) {
-	if !l.enabled || l.level < LogLevelInfo {
+	if !l.allows(LogLevelInfo) {
 		return
 	}
 
@@ -252,7 +302,7 @@ func (l *Logger) LogAlternativeToolsCompletion(agentName string, request openai.
 		entry.Error = err.Error()
 	} else {
 		entry.Message = fmt.Sprintf("Alternative tools completion successful with %d tool calls", len(toolCalls))
-		if l.level >= LogLevelDebug {
+		if l.allows(LogLevelDebug) {
 			toolNames := make([]string, len(toolCalls))
 			for i, tc := range toolCalls {
 				toolNames[i] = tc.Function.Name
@@ -265,7 +315,7 @@ func (l *Logger) LogAlternativeToolsCompletion(agentName string, request openai.
 }
 
 func (l *Logger) LogError(agentName string, errorType string, message string, err error, context map[string]interface{}) {
-	if !l.enabled || l.level < LogLevelError {
+	if !l.allows(LogLevelError) {
 		return
 	}
 
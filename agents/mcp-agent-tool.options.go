@@ -0,0 +1,47 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithMCPAgentTool exposes the agent as a tool of its MCP server
+// (WithMCPStreamableHttpServer or WithMCPStdioServer must be applied before).
+// The tool takes a "prompt" argument, runs the agent with it (Run: chat completion with the agent's own tool loop,
+// using the tools registered with RegisterTool and the MCP tools of the agent) and returns the answer.
+// Each call starts from the messages of the agent (e.g. the system instructions), the agent's messages are never modified.
+func WithMCPAgentTool(name string, description string) AgentOption {
+	return func(agent *Agent) {
+		if agent.mcpServer == nil {
+			agent.optionError = errors.New("the MCP server is not configured, use WithMCPStreamableHttpServer or WithMCPStdioServer before WithMCPAgentTool")
+			return
+		}
+
+		agentTool := mcp.NewTool(name,
+			mcp.WithDescription(description),
+			mcp.WithString("prompt",
+				mcp.Required(),
+				mcp.Description("The request for the agent "+agent.Name),
+			),
+		)
+
+		agent.AddToolToMCPServer(agentTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			prompt, err := request.RequireString("prompt")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result, err := agent.withMessages(slices.Clone(agent.Params.Messages)).Run(ctx, prompt, RunOptions{})
+			if err != nil {
+				agent.logger.LogError(agent.Name, "mcp_agent_tool", "Failed to run the agent", err, map[string]any{
+					"tool_name": name,
+				})
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return mcp.NewToolResultText(result.Answer), nil
+		})
+	}
+}
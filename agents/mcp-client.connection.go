@@ -0,0 +1,282 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Default values of MCPClientConfig.
+const (
+	DefaultMCPMaxReconnectAttempts = 3
+	DefaultMCPReconnectBackoff     = 500 * time.Millisecond
+	// DefaultMCPHealthCheckTimeout is the maximum duration of the health check pings when CallTimeout is not set.
+	DefaultMCPHealthCheckTimeout = 5 * time.Second
+)
+
+// MCPClientConfig configures the resilience of a named MCP client (see WithMCPClientConfig).
+type MCPClientConfig struct {
+	// CallTimeout is the maximum duration of each request to the MCP server (0: no timeout, only the context cancellation).
+	CallTimeout time.Duration
+	// HealthCheckInterval is the interval of the pings checking the MCP server (0: no health checks).
+	// A failed ping reconnects the client. The pings time out after CallTimeout (default: DefaultMCPHealthCheckTimeout).
+	HealthCheckInterval time.Duration
+	// MaxReconnectAttempts is the number of reconnection attempts when the MCP server is unreachable
+	// (default: DefaultMCPMaxReconnectAttempts, negative: no reconnection).
+	// A stdio client restarts the command of the server, an HTTP client opens a new session.
+	MaxReconnectAttempts int
+	// ReconnectBackoff is the delay before the first reconnection attempt, doubled after each attempt
+	// (default: DefaultMCPReconnectBackoff).
+	ReconnectBackoff time.Duration
+	// ListenForNotifications keeps a connection open to the Streamable HTTP server to receive its notifications
+	// (e.g. tools/list_changed). The stdio clients always receive the notifications.
+	ListenForNotifications bool
+}
+
+// WithMCPClientConfig configures the timeouts, the health checks and the reconnection of the named MCP client
+// (e.g. "stdio" or "http"). It must be applied before the option creating the client.
+func WithMCPClientConfig(clientName string, config MCPClientConfig) AgentOption {
+	return func(agent *Agent) {
+		if _, exists := agent.mcpClients[clientName]; exists {
+			agent.optionError = fmt.Errorf("the configuration of the MCP client %s must be applied before its creation", clientName)
+			return
+		}
+		if agent.mcpClientConfigs == nil {
+			agent.mcpClientConfigs = make(map[string]MCPClientConfig)
+		}
+		agent.mcpClientConfigs[clientName] = config
+	}
+}
+
+// mcpConnection is a named MCP client that reconnects when the MCP server is unreachable
+// (e.g. the stdio subprocess died or the HTTP server restarted).
+type mcpConnection struct {
+	name    string
+	config  MCPClientConfig
+	connect func(ctx context.Context) (*client.Client, error)
+
+	// mutex protects client
+	mutex  sync.Mutex
+	client *client.Client
+	// reconnecting serializes the reconnections (a semaphore, so the waiting requests can be cancelled)
+	reconnecting chan struct{}
+
+	// toolsVersion is incremented by the tools/list_changed notifications and the reconnections:
+	// each agent (or copy of an agent) compares it with the version of its tools (see Agent.refreshChangedMCPTools)
+	toolsVersion atomic.Uint64
+	// toolsFilter is the filter of the tools added to the agent (nil: the tools of the client are not used)
+	toolsFilter []string
+	hasTools    bool
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// newMCPConnection connects to the MCP server and starts the health checks.
+func newMCPConnection(ctx context.Context, name string, config MCPClientConfig, connect func(ctx context.Context) (*client.Client, error)) (*mcpConnection, error) {
+	if config.MaxReconnectAttempts == 0 {
+		config.MaxReconnectAttempts = DefaultMCPMaxReconnectAttempts
+	}
+	if config.ReconnectBackoff <= 0 {
+		config.ReconnectBackoff = DefaultMCPReconnectBackoff
+	}
+	connection := &mcpConnection{
+		name:    name,
+		config:  config,
+		connect: connect,
+		stop:    make(chan struct{}),
+
+		reconnecting: make(chan struct{}, 1),
+	}
+	mcpClient, err := connection.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	connection.client = mcpClient
+
+	if config.HealthCheckInterval > 0 {
+		go connection.healthCheck()
+	}
+	return connection, nil
+}
+
+// dial creates and initializes a new client, listening to the tools/list_changed notifications.
+func (connection *mcpConnection) dial(ctx context.Context) (*client.Client, error) {
+	mcpClient, err := connection.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mcpClient.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationToolsListChanged {
+			connection.toolsVersion.Add(1)
+		}
+	})
+	return mcpClient, nil
+}
+
+func (connection *mcpConnection) current() *client.Client {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.client
+}
+
+// do runs the request with the current client and the CallTimeout.
+// If the request fails and the MCP server does not answer to a ping, the client reconnects and the request is retried once.
+// NOTE: only for the idempotent requests (e.g. list the tools), see doOnce.
+func (connection *mcpConnection) do(ctx context.Context, request func(ctx context.Context, mcpClient *client.Client) error) error {
+	return connection.run(ctx, true, request)
+}
+
+// doOnce runs the request like do, but never retries it: the request may have been executed
+// by the MCP server before the failure (e.g. a tool call). The client still reconnects for the next requests.
+func (connection *mcpConnection) doOnce(ctx context.Context, request func(ctx context.Context, mcpClient *client.Client) error) error {
+	return connection.run(ctx, false, request)
+}
+
+func (connection *mcpConnection) run(ctx context.Context, retry bool, request func(ctx context.Context, mcpClient *client.Client) error) error {
+	mcpClient := connection.current()
+	err := connection.withTimeout(ctx, func(ctx context.Context) error {
+		return request(ctx, mcpClient)
+	})
+	if err == nil || ctx.Err() != nil || connection.alive(ctx, mcpClient) {
+		return err
+	}
+
+	mcpClient, reconnectErr := connection.reconnect(ctx, mcpClient)
+	if reconnectErr != nil {
+		return errors.Join(err, reconnectErr)
+	}
+	if !retry {
+		return fmt.Errorf("%w (MCP client %s reconnected, the request was not retried)", err, connection.name)
+	}
+	return connection.withTimeout(ctx, func(ctx context.Context) error {
+		return request(ctx, mcpClient)
+	})
+}
+
+func (connection *mcpConnection) withTimeout(ctx context.Context, request func(ctx context.Context) error) error {
+	if connection.config.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, connection.config.CallTimeout)
+		defer cancel()
+	}
+	return request(ctx)
+}
+
+// alive pings the MCP server.
+func (connection *mcpConnection) alive(ctx context.Context, mcpClient *client.Client) bool {
+	return connection.withTimeout(ctx, mcpClient.Ping) == nil
+}
+
+// reconnect replaces the failed client with a new one, with MaxReconnectAttempts attempts and an exponential backoff.
+// If the client was already replaced (e.g. by a concurrent request), the new client is returned.
+// NOTE: the mutex is not held during the backoff and the dial, only one reconnection runs at a time.
+func (connection *mcpConnection) reconnect(ctx context.Context, failed *client.Client) (*client.Client, error) {
+	select {
+	case connection.reconnecting <- struct{}{}:
+		defer func() { <-connection.reconnecting }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-connection.stop:
+		return nil, fmt.Errorf("MCP client %s is closed", connection.name)
+	}
+
+	if current := connection.current(); current != failed {
+		return current, nil
+	}
+	if connection.config.MaxReconnectAttempts < 0 {
+		return nil, fmt.Errorf("MCP client %s: the server is unreachable", connection.name)
+	}
+
+	backoff := connection.config.ReconnectBackoff
+	var err error
+	for range connection.config.MaxReconnectAttempts {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-connection.stop:
+			return nil, fmt.Errorf("MCP client %s is closed", connection.name)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		var mcpClient *client.Client
+		mcpClient, err = connection.dial(ctx)
+		if err == nil {
+			return connection.replace(mcpClient)
+		}
+	}
+	return nil, fmt.Errorf("MCP client %s: reconnection failed after %d attempts: %w", connection.name, connection.config.MaxReconnectAttempts, err)
+}
+
+// replace makes mcpClient the current client and closes the failed one, unless the connection was closed during the reconnection.
+// NOTE: the failed client stays the current client until it is replaced: if the reconnection fails,
+// the next request fails with the reconnection error (see run) and close closes it only once.
+func (connection *mcpConnection) replace(mcpClient *client.Client) (*client.Client, error) {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	select {
+	case <-connection.stop:
+		mcpClient.Close()
+		return nil, fmt.Errorf("MCP client %s is closed", connection.name)
+	default:
+	}
+	failed := connection.client
+	connection.client = mcpClient
+	failed.Close()
+	// NOTE: the tools of the new session may be different
+	connection.toolsVersion.Add(1)
+	return mcpClient, nil
+}
+
+// healthCheck pings the MCP server every HealthCheckInterval and reconnects the client when the ping fails.
+// The pings time out after CallTimeout (or DefaultMCPHealthCheckTimeout), the reconnections stop when the client is closed.
+func (connection *mcpConnection) healthCheck() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-connection.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(connection.config.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-connection.stop:
+			return
+		case <-ticker.C:
+			connection.checkHealth(ctx)
+		}
+	}
+}
+
+func (connection *mcpConnection) checkHealth(ctx context.Context) {
+	timeout := connection.config.CallTimeout
+	if timeout <= 0 {
+		timeout = DefaultMCPHealthCheckTimeout
+	}
+	mcpClient := connection.current()
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	alive := connection.alive(pingCtx, mcpClient)
+	cancel()
+	if !alive {
+		connection.reconnect(ctx, mcpClient)
+	}
+}
+
+// close stops the health checks and closes the client.
+func (connection *mcpConnection) close() error {
+	var err error
+	connection.closeOnce.Do(func() {
+		close(connection.stop)
+		err = connection.current().Close()
+	})
+	return err
+}
@@ -0,0 +1,183 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/openai/openai-go"
+)
+
+// --- Resources ---
+
+// ListMCPResources lists the resources of the MCP server of the client (e.g. "stdio" or "http").
+// The pages of the paginated results are requested one by one (a page is retried after a reconnection) until the last one.
+func (agent *Agent) ListMCPResources(ctx context.Context, clientName string) ([]mcp.Resource, error) {
+	connection, err := agent.mcpClient(clientName)
+	if err != nil {
+		return nil, err
+	}
+	resources := []mcp.Resource{}
+	request := mcp.ListResourcesRequest{}
+	for {
+		var result *mcp.ListResourcesResult
+		err = connection.do(ctx, func(ctx context.Context, mcpClient *client.Client) error {
+			page, err := mcpClient.ListResourcesByPage(ctx, request)
+			result = page
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, result.Resources...)
+		if result.NextCursor == "" {
+			return resources, nil
+		}
+		request.Params.Cursor = result.NextCursor
+	}
+}
+
+// ReadMCPResource reads the resource with the given URI from the MCP server of the client (e.g. "stdio" or "http").
+// It returns the text contents of the resource; the binary contents are replaced by a short description.
+func (agent *Agent) ReadMCPResource(ctx context.Context, clientName string, uri string) (string, error) {
+	connection, err := agent.mcpClient(clientName)
+	if err != nil {
+		return "", err
+	}
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = uri
+	var result *mcp.ReadResourceResult
+	err = connection.do(ctx, func(ctx context.Context, mcpClient *client.Client) error {
+		read, err := mcpClient.ReadResource(ctx, request)
+		result = read
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	contents := []string{}
+	for _, content := range result.Contents {
+		switch content := content.(type) {
+		case mcp.TextResourceContents:
+			contents = append(contents, content.Text)
+		case mcp.BlobResourceContents:
+			contents = append(contents, fmt.Sprintf("[binary content %s (%s)]", content.URI, content.MIMEType))
+		}
+	}
+	return strings.Join(contents, "\n"), nil
+}
+
+// AddMCPResourcesToMessages reads the resources from the MCP server of the client (e.g. "stdio" or "http")
+// and adds them to the Agent's messages as system messages (the context of the conversation).
+func (agent *Agent) AddMCPResourcesToMessages(ctx context.Context, clientName string, uris ...string) error {
+	for _, uri := range uris {
+		content, err := agent.ReadMCPResource(ctx, clientName, uri)
+		if err != nil {
+			return err
+		}
+		agent.Params.Messages = append(agent.Params.Messages, openai.SystemMessage("CONTEXT ("+uri+"):\n"+content))
+	}
+	return nil
+}
+
+// WithMCPResources adds the resources of the MCP server of the client (e.g. "stdio" or "http") to the Agent's messages
+// as system messages (the MCP client option must be applied before).
+func WithMCPResources(ctx context.Context, clientName string, uris ...string) AgentOption {
+	return func(agent *Agent) {
+		if err := agent.AddMCPResourcesToMessages(ctx, clientName, uris...); err != nil {
+			agent.optionError = err
+		}
+	}
+}
+
+// --- Prompts ---
+
+// ListMCPPrompts lists the prompts of the MCP server of the client (e.g. "stdio" or "http").
+// The pages of the paginated results are requested one by one (a page is retried after a reconnection) until the last one.
+func (agent *Agent) ListMCPPrompts(ctx context.Context, clientName string) ([]mcp.Prompt, error) {
+	connection, err := agent.mcpClient(clientName)
+	if err != nil {
+		return nil, err
+	}
+	prompts := []mcp.Prompt{}
+	request := mcp.ListPromptsRequest{}
+	for {
+		var result *mcp.ListPromptsResult
+		err = connection.do(ctx, func(ctx context.Context, mcpClient *client.Client) error {
+			page, err := mcpClient.ListPromptsByPage(ctx, request)
+			result = page
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, result.Prompts...)
+		if result.NextCursor == "" {
+			return prompts, nil
+		}
+		request.Params.Cursor = result.NextCursor
+	}
+}
+
+// GetMCPPrompt gets the prompt with the given name and arguments from the MCP server of the client (e.g. "stdio" or "http")
+// and converts its messages to chat completion messages (see mcpContentText).
+func (agent *Agent) GetMCPPrompt(ctx context.Context, clientName string, name string, arguments map[string]string) ([]openai.ChatCompletionMessageParamUnion, error) {
+	connection, err := agent.mcpClient(clientName)
+	if err != nil {
+		return nil, err
+	}
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	var result *mcp.GetPromptResult
+	err = connection.do(ctx, func(ctx context.Context, mcpClient *client.Client) error {
+		prompt, err := mcpClient.GetPrompt(ctx, request)
+		result = prompt
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []openai.ChatCompletionMessageParamUnion{}
+	for _, promptMessage := range result.Messages {
+		text := mcpContentText(promptMessage.Content)
+		if text == "" {
+			continue
+		}
+		if promptMessage.Role == mcp.RoleAssistant {
+			messages = append(messages, openai.AssistantMessage(text))
+		} else {
+			messages = append(messages, openai.UserMessage(text))
+		}
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("the prompt has no text message: " + name)
+	}
+	return messages, nil
+}
+
+// AddMCPPromptToMessages gets the prompt from the MCP server of the client (e.g. "stdio" or "http")
+// and adds its messages to the Agent's messages.
+func (agent *Agent) AddMCPPromptToMessages(ctx context.Context, clientName string, name string, arguments map[string]string) error {
+	messages, err := agent.GetMCPPrompt(ctx, clientName, name, arguments)
+	if err != nil {
+		return err
+	}
+	agent.Params.Messages = append(agent.Params.Messages, messages...)
+	return nil
+}
+
+// WithMCPPrompt adds the messages of the prompt of the MCP server of the client (e.g. "stdio" or "http")
+// to the Agent's messages (the MCP client option must be applied before).
+func WithMCPPrompt(ctx context.Context, clientName string, name string, arguments map[string]string) AgentOption {
+	return func(agent *Agent) {
+		if err := agent.AddMCPPromptToMessages(ctx, clientName, name, arguments); err != nil {
+			agent.optionError = err
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/budgies-nest/budgie/helpers"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/openai/openai-go"
+)
+
+// Names of the MCP clients created by WithMCPStdioClient and WithMCPStreamableHttpClient.
+const (
+	DefaultMCPStdioClientName          = "stdio"
+	DefaultMCPStreamableHTTPClientName = "http"
+)
+
+// mcpClientNamePattern keeps the client names usable in the tool names (namespaced tools).
+var mcpClientNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// mcpToolOrigin is the MCP client providing a tool and the name of the tool on the MCP server
+// (the name seen by the model is different when the tool is namespaced).
+type mcpToolOrigin struct {
+	clientName string
+	toolName   string
+}
+
+// addMCPClient connects the named MCP client (see MCPClientConfig for the reconnection) and registers it.
+// connect creates and initializes the client, it is called again to reconnect.
+func (agent *Agent) addMCPClient(ctx context.Context, name string, connect func(ctx context.Context) (*client.Client, error)) error {
+	if !mcpClientNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid MCP client name %q: only letters, digits, '_' and '-' are allowed", name)
+	}
+	if _, exists := agent.mcpClients[name]; exists {
+		return fmt.Errorf("MCP client %s already exists", name)
+	}
+	connection, err := newMCPConnection(ctx, name, agent.mcpClientConfigs[name], connect)
+	if err != nil {
+		return err
+	}
+	if agent.mcpClients == nil {
+		agent.mcpClients = make(map[string]*mcpConnection)
+	}
+	agent.mcpClients[name] = connection
+	return nil
+}
+
+// mcpClient returns the MCP client with the given name (e.g. "stdio" or "http").
+func (agent *Agent) mcpClient(name string) (*mcpConnection, error) {
+	connection, ok := agent.mcpClients[name]
+	if !ok || connection == nil {
+		return nil, fmt.Errorf("MCP client %s is not initialized", name)
+	}
+	return connection, nil
+}
+
+// MCPClientNames returns the sorted names of the MCP clients of the agent.
+func (agent *Agent) MCPClientNames() []string {
+	names := make([]string, 0, len(agent.mcpClients))
+	for name := range agent.mcpClients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithMCPClientTools fetches the tools of the named MCP client and adds them to the Agent's tools.
+// If no toolsFilter are specified, all available tools are used.
+// If toolsFilter is specified, only the tools matching the filter (names on the MCP server) are used.
+// A tool whose name is already used by another tool of the agent is namespaced: {client name}__{tool name}.
+// The tool calls are routed to the client by ExecuteToolCalls and Run.
+func WithMCPClientTools(ctx context.Context, clientName string, toolsFilter []string) AgentOption {
+	return func(agent *Agent) {
+		if err := agent.addMCPTools(ctx, clientName, toolsFilter); err != nil {
+			agent.optionError = err
+		}
+	}
+}
+
+// addMCPTools fetches the tools of the named MCP client, namespaces the colliding names
+// and appends the tools to the Agent's tools.
+func (agent *Agent) addMCPTools(ctx context.Context, clientName string, toolsFilter []string) error {
+	connection, err := agent.mcpClient(clientName)
+	if err != nil {
+		return err
+	}
+	version := connection.toolsVersion.Load()
+	tools, err := agent.listMCPTools(ctx, connection, toolsFilter)
+	if err != nil {
+		return err
+	}
+	if len(tools) == 0 && len(toolsFilter) > 0 {
+		return errors.New("no tools found matching the filter")
+	}
+	connection.hasTools = true
+	connection.toolsFilter = toolsFilter
+	agent.appendMCPTools(clientName, tools)
+	agent.setMCPToolsVersion(clientName, version)
+	return nil
+}
+
+// listMCPTools fetches the tools of the MCP client and converts them to OpenAI format.
+// If toolsFilter is not empty, only the tools matching the filter are returned.
+func (agent *Agent) listMCPTools(ctx context.Context, connection *mcpConnection, toolsFilter []string) ([]openai.ChatCompletionToolParam, error) {
+	var mcpTools *mcp.ListToolsResult
+	err := connection.do(ctx, func(ctx context.Context, mcpClient *client.Client) error {
+		var err error
+		mcpTools, err = mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tools := helpers.ConvertMCPToolsToOpenAITools(mcpTools)
+	if len(toolsFilter) > 0 {
+		tools = slices.DeleteFunc(tools, func(tool openai.ChatCompletionToolParam) bool {
+			return !slices.Contains(toolsFilter, tool.Function.Name)
+		})
+	}
+	return tools, nil
+}
+
+// appendMCPTools appends the tools of the MCP client to the Agent's tools,
+// namespacing the names already used: {client name}__{tool name}.
+func (agent *Agent) appendMCPTools(clientName string, tools []openai.ChatCompletionToolParam) {
+	if agent.mcpToolsOrigin == nil {
+		agent.mcpToolsOrigin = make(map[string]mcpToolOrigin)
+	}
+	for _, tool := range tools {
+		origin := mcpToolOrigin{clientName: clientName, toolName: tool.Function.Name}
+		if agent.hasTool(tool.Function.Name) {
+			tool.Function.Name = clientName + "__" + tool.Function.Name
+		}
+		agent.mcpToolsOrigin[tool.Function.Name] = origin
+		agent.Params.Tools = append(agent.Params.Tools, tool)
+	}
+}
+
+// RefreshMCPTools fetches again the tools of the named MCP client (with the same filter)
+// and replaces them in the Agent's tools.
+// It is called automatically by the tools completions when the MCP server notifies that its tools changed (tools/list_changed).
+// NOTE: it only changes this agent: the copies of the agent (e.g. the HTTP sessions) refresh their own tools.
+func (agent *Agent) RefreshMCPTools(ctx context.Context, clientName string) error {
+	connection, err := agent.mcpClient(clientName)
+	if err != nil {
+		return err
+	}
+	if !connection.hasTools {
+		return fmt.Errorf("the tools of the MCP client %s are not used by the agent", clientName)
+	}
+	version := connection.toolsVersion.Load()
+	tools, err := agent.listMCPTools(ctx, connection, connection.toolsFilter)
+	if err != nil {
+		return err // NOTE: the version is not updated, the next completion tries again
+	}
+
+	agent.Params.Tools = slices.DeleteFunc(agent.Params.Tools, func(tool openai.ChatCompletionToolParam) bool {
+		origin, ok := agent.mcpToolsOrigin[tool.Function.Name]
+		return ok && origin.clientName == clientName
+	})
+	for name, origin := range agent.mcpToolsOrigin {
+		if origin.clientName == clientName {
+			delete(agent.mcpToolsOrigin, name)
+		}
+	}
+	agent.appendMCPTools(clientName, tools)
+	agent.setMCPToolsVersion(clientName, version)
+	return nil
+}
+
+// setMCPToolsVersion records the version of the tools of the MCP client in the Agent's tools.
+func (agent *Agent) setMCPToolsVersion(clientName string, version uint64) {
+	if agent.mcpToolsVersions == nil {
+		agent.mcpToolsVersions = make(map[string]uint64)
+	}
+	agent.mcpToolsVersions[clientName] = version
+}
+
+// refreshChangedMCPTools refreshes the tools of the MCP clients whose server notified a change (or reconnected).
+// The errors are logged: the completion goes on with the previous tools.
+func (agent *Agent) refreshChangedMCPTools(ctx context.Context) {
+	for _, name := range agent.MCPClientNames() {
+		connection := agent.mcpClients[name]
+		if !connection.hasTools || connection.toolsVersion.Load() == agent.mcpToolsVersions[name] {
+			continue
+		}
+		if err := agent.RefreshMCPTools(ctx, name); err != nil {
+			agent.logger.LogError(agent.Name, "mcp_tools", "Failed to refresh the MCP tools", err, map[string]any{
+				"client_name": name,
+			})
+		}
+	}
+}
+
+// hasTool reports whether a tool with the given name is in the Agent's tools.
+func (agent *Agent) hasTool(name string) bool {
+	return slices.ContainsFunc(agent.Params.Tools, func(tool openai.ChatCompletionToolParam) bool {
+		return tool.Function.Name == name
+	})
+}
+
+// closeMCPClients closes and removes all the MCP clients.
+func (agent *Agent) closeMCPClients() error {
+	var errs []error
+	for name, connection := range agent.mcpClients {
+		if err := connection.close(); err != nil {
+			errs = append(errs, fmt.Errorf("MCP client %s: %w", name, err))
+		}
+	}
+	clear(agent.mcpClients)
+	return errors.Join(errs...)
+}
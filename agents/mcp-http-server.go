@@ -1,6 +1,12 @@
 package agents
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -14,12 +20,116 @@ func (agent *Agent) AddToolToMCPServer(tool mcp.Tool, handler server.ToolHandler
 	agent.mcpServer.AddTool(tool, handler)
 }
 
+// AddResourceToMCPServer publishes a resource on the MCP server (the handler returns its contents).
+func (agent *Agent) AddResourceToMCPServer(resource mcp.Resource, handler server.ResourceHandlerFunc) {
+	if agent.mcpServer == nil {
+		// If the MCP server is not initialized, we cannot add resources
+		return
+	}
+	agent.mcpServer.AddResource(resource, handler)
+}
+
+// AddTextResourceToMCPServer publishes a static text resource on the MCP server.
+func (agent *Agent) AddTextResourceToMCPServer(uri string, name string, description string, mimeType string, text string) {
+	resource := mcp.NewResource(uri, name,
+		mcp.WithResourceDescription(description),
+		mcp.WithMIMEType(mimeType),
+	)
+	agent.AddResourceToMCPServer(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: text},
+		}, nil
+	})
+}
+
+// AddRAGDocumentsToMCPServer publishes the documents of the agent's vector store on the MCP server,
+// one text resource per record: {uriPrefix}{record id} (default prefix: "rag://{agent name}/").
+// The records saved after the call are not published.
+func (agent *Agent) AddRAGDocumentsToMCPServer(uriPrefix string) error {
+	if agent.mcpServer == nil {
+		return errors.New("MCP server is not configured")
+	}
+	if agent.Store == nil {
+		return errors.New("the vector store is not initialized")
+	}
+	if uriPrefix == "" {
+		uriPrefix = "rag://" + agent.Name + "/"
+	}
+	records, err := agent.Store.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		agent.AddTextResourceToMCPServer(
+			uriPrefix+record.Id,
+			record.Id,
+			fmt.Sprintf("Document %s of the %s agent", record.Id, agent.Name),
+			"text/plain",
+			record.Prompt,
+		)
+	}
+	return nil
+}
+
+// AddPromptToMCPServer publishes a prompt on the MCP server (the handler returns its messages).
+func (agent *Agent) AddPromptToMCPServer(prompt mcp.Prompt, handler server.PromptHandlerFunc) {
+	if agent.mcpServer == nil {
+		// If the MCP server is not initialized, we cannot add prompts
+		return
+	}
+	agent.mcpServer.AddPrompt(prompt, handler)
+}
+
+// AddPromptTemplateToMCPServer publishes a prompt template on the MCP server.
+// The {{argument}} placeholders of the template are replaced by the values of the (required) arguments,
+// and the result is sent as a user message.
+func (agent *Agent) AddPromptTemplateToMCPServer(name string, description string, template string, arguments ...string) {
+	options := []mcp.PromptOption{mcp.WithPromptDescription(description)}
+	for _, argument := range arguments {
+		options = append(options, mcp.WithArgument(argument, mcp.RequiredArgument()))
+	}
+	agent.AddPromptToMCPServer(mcp.NewPrompt(name, options...), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		text := template
+		for _, argument := range arguments {
+			value, ok := request.Params.Arguments[argument]
+			if !ok {
+				return nil, fmt.Errorf("missing argument: %s", argument)
+			}
+			text = strings.ReplaceAll(text, "{{"+argument+"}}", value)
+		}
+		return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	})
+}
+
+// StartMCPHttpServer starts the MCP Streamable HTTP server and blocks until it stops.
 func (agent *Agent) StartMCPHttpServer() error {
-	return server.NewStreamableHTTPServer(agent.mcpServer,
+	agentServer, err := agent.StartMCPHttpServerAsync()
+	if err != nil {
+		return err
+	}
+	return agentServer.Wait()
+}
+
+// StartMCPHttpServerAsync starts the MCP Streamable HTTP server in the background and returns it.
+func (agent *Agent) StartMCPHttpServerAsync() (*AgentServer, error) {
+	if agent.mcpServer == nil {
+		return nil, errors.New("the MCP server is not configured (WithMCPStreamableHttpServer)")
+	}
+	return agent.startServer(":"+agent.mcpServerConfig.Port, agent.MCPHttpHandler(), nil, nil)
+}
+
+// MCPHttpHandler returns the handler of the MCP Streamable HTTP server (on the MCPServerConfig endpoint)
+// with the security of the agent (e.g. for httptest).
+func (agent *Agent) MCPHttpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(agent.mcpServerConfig.Endpoint, server.NewStreamableHTTPServer(agent.mcpServer,
 		server.WithEndpointPath(agent.mcpServerConfig.Endpoint),
-	).Start(":" + agent.mcpServerConfig.Port)
+	))
+	return agent.secureHandler(mux)
 }
 
 func (agent *Agent) MCPServerConfig() MCPServerConfig {
 	return agent.mcpServerConfig
-}
\ No newline at end of file
+}
@@ -1,9 +1,5 @@
 package agents
 
-import (
-	"github.com/mark3labs/mcp-go/server"
-)
-
 type MCPServerConfig struct {
 	Name     string
 	Version  string
@@ -13,6 +9,10 @@ type MCPServerConfig struct {
 
 func WithMCPStreamableHttpServer(mcpServerConfig MCPServerConfig) AgentOption {
 	return func(agent *Agent) {
+		// Create MCP server (shared with the stdio server, see WithMCPStdioServer)
+		if !agent.ensureMCPServer(mcpServerConfig) {
+			return
+		}
 		agent.mcpServerConfig = mcpServerConfig
 
 		if mcpServerConfig.Endpoint == "" {
@@ -24,11 +24,5 @@ func WithMCPStreamableHttpServer(mcpServerConfig MCPServerConfig) AgentOption {
 			agent.mcpServerConfig.Port = "9090"
 		}
 
-		// Create MCP server
-		agent.mcpServer = server.NewMCPServer(
-			mcpServerConfig.Name,
-			mcpServerConfig.Version,
-		)
-
 	}
 }
@@ -0,0 +1,73 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithMCPStdioServer creates the MCP server of the agent for StartMCPStdioServer (only the Name and the Version are used).
+// The tools, resources and prompts are added with AddToolToMCPServer, AddResourceToMCPServer and AddPromptToMCPServer.
+// The MCP server is shared with WithMCPStreamableHttpServer: the same tools can be served over stdio and HTTP,
+// so the two options must use the same Name and Version (otherwise NewAgent returns an error).
+func WithMCPStdioServer(mcpServerConfig MCPServerConfig) AgentOption {
+	return func(agent *Agent) {
+		if agent.ensureMCPServer(mcpServerConfig) {
+			agent.mcpServerConfig.Name = mcpServerConfig.Name
+			agent.mcpServerConfig.Version = mcpServerConfig.Version
+		}
+	}
+}
+
+// ensureMCPServer creates the MCP server of the agent, shared by the stdio and the Streamable HTTP servers.
+// It returns false if the MCP server already exists with another name or version (the option error of the agent is set).
+func (agent *Agent) ensureMCPServer(mcpServerConfig MCPServerConfig) bool {
+	if agent.mcpServer != nil {
+		if agent.mcpServerConfig.Name != mcpServerConfig.Name || agent.mcpServerConfig.Version != mcpServerConfig.Version {
+			agent.optionError = fmt.Errorf("the MCP server is already configured as %s %s, it cannot be configured as %s %s",
+				agent.mcpServerConfig.Name, agent.mcpServerConfig.Version, mcpServerConfig.Name, mcpServerConfig.Version)
+			return false
+		}
+		return true
+	}
+	agent.mcpServer = server.NewMCPServer(
+		mcpServerConfig.Name,
+		mcpServerConfig.Version,
+	)
+	return true
+}
+
+// StartMCPStdioServer serves the tools, resources and prompts of the MCP server over stdin/stdout
+// (e.g. for the desktop hosts or the Docker MCP gateway launching the agent as a subprocess).
+// It blocks until stdin is closed or the process receives SIGINT or SIGTERM.
+// IMPORTANT: stdout is reserved for the MCP protocol, the logs of the agent are written to stderr.
+func (agent *Agent) StartMCPStdioServer() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return agent.ServeMCPStdio(ctx, os.Stdin, os.Stdout)
+}
+
+// ServeMCPStdio serves the MCP server with the given input and output until the input is closed or ctx is cancelled.
+// While it serves, the logs written to stdout by the logger of the agent (e.g. the global logger, shared with the other agents)
+// are written to stderr; the logger writes to stdout again when it returns.
+func (agent *Agent) ServeMCPStdio(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
+	if agent.mcpServer == nil {
+		return errors.New("MCP server is not configured")
+	}
+	defer agent.logger.reserveStdout()()
+
+	stdioServer := server.NewStdioServer(agent.mcpServer)
+	stdioServer.SetErrorLogger(log.New(os.Stderr, "", log.LstdFlags))
+	err := stdioServer.Listen(ctx, stdin, stdout)
+	if errors.Is(err, context.Canceled) || errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
@@ -8,33 +8,39 @@ import (
 
 func WithDMR(baseURL string) AgentOption {
 	return func(agent *Agent) {
-		agent.clientEngine = openai.NewClient(
+		agent.useProvider(NewOpenAIProvider(
 			option.WithBaseURL(baseURL),
 			option.WithAPIKey(""),
-		)
+		))
 	}
 }
 
 func WithOpenAI(apiKey string) AgentOption {
 	return func(agent *Agent) {
-		agent.clientEngine = openai.NewClient(
+		agent.useProvider(NewOpenAIProvider(
 			option.WithBaseURL(base.OpenAIURL),
 			option.WithAPIKey(apiKey),
-		)
+		))
 	}
 }
 
 func WithOpenAIURL(baseURL string, apiKey string) AgentOption {
 	return func(agent *Agent) {
-		agent.clientEngine = openai.NewClient(
+		agent.useProvider(NewOpenAIProvider(
 			option.WithBaseURL(baseURL),
 			option.WithAPIKey(apiKey),
-		)
+		))
 	}
 }
 
 // TODO: add more client options
 
+// useProvider sets the provider of the chat completions and of the embeddings.
+func (agent *Agent) useProvider(provider *OpenAIProvider) {
+	agent.chatProvider = provider
+	agent.embeddingProvider = provider
+}
+
 // WithParams sets the parameters for the Agent's chat completion requests.
 func WithParams(params openai.ChatCompletionNewParams) AgentOption {
 	return func(agent *Agent) {
@@ -51,10 +57,11 @@ func WithEmbeddingParams(embeddingParams openai.EmbeddingNewParams) AgentOption
 
 // WithTools sets the tools for the Agent's chat completion requests.
 // It allows the Agent to use specific tools during the chat completion process.
-// IMPORTANT: The tools are appended to the existing tools in the Agent's parameters.
+// IMPORTANT: The tools are appended to the existing tools in the Agent's parameters,
+// a tool with the same name (e.g. registered with RegisterTool) is replaced.
 func WithTools(tools []openai.ChatCompletionToolParam) AgentOption {
 	return func(agent *Agent) {
-		agent.Params.Tools = append(agent.Params.Tools, tools...)
+		agent.AddTools(tools)
 	}
 }
 
@@ -0,0 +1,78 @@
+package agents
+
+import (
+	"context"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// ChatProvider sends the chat completion requests of the agent to a model
+// (ChatCompletion, ChatCompletionStream, ToolsCompletion, Run, the conversation summaries...).
+// The requests and the responses use the types of the OpenAI API: a provider for another API converts them
+// (see OllamaProvider).
+type ChatProvider interface {
+	// ChatCompletion returns the completion of the request.
+	ChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error)
+	// ChatCompletionStream streams the completion of the request: onChunk is called for each chunk.
+	// If onChunk returns an error, the stream stops and the error is returned.
+	ChatCompletionStream(ctx context.Context, params openai.ChatCompletionNewParams, onChunk func(chunk openai.ChatCompletionChunk) error) error
+}
+
+// EmbeddingProvider creates the embeddings of the agent (RAG memory, CreateEmbeddingFromText...).
+type EmbeddingProvider interface {
+	CreateEmbeddings(ctx context.Context, params openai.EmbeddingNewParams) (*openai.CreateEmbeddingResponse, error)
+}
+
+// OpenAIProvider is the default provider of the agents: it uses the OpenAI SDK with any OpenAI-compatible API
+// (Docker Model Runner, OpenAI, ...). It is both a ChatProvider and an EmbeddingProvider.
+type OpenAIProvider struct {
+	client openai.Client
+}
+
+// NewOpenAIProvider creates an OpenAI provider with the options of the OpenAI client, e.g.:
+//
+//	agents.NewOpenAIProvider(option.WithBaseURL(baseURL), option.WithAPIKey(apiKey))
+func NewOpenAIProvider(options ...option.RequestOption) *OpenAIProvider {
+	return &OpenAIProvider{client: openai.NewClient(options...)}
+}
+
+func (provider *OpenAIProvider) ChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	return provider.client.Chat.Completions.New(ctx, params)
+}
+
+func (provider *OpenAIProvider) ChatCompletionStream(ctx context.Context, params openai.ChatCompletionNewParams, onChunk func(chunk openai.ChatCompletionChunk) error) error {
+	stream := provider.client.Chat.Completions.NewStreaming(ctx, params)
+	for stream.Next() {
+		if err := onChunk(stream.Current()); err != nil {
+			stream.Close()
+			return err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		stream.Close()
+		return err
+	}
+	return stream.Close()
+}
+
+func (provider *OpenAIProvider) CreateEmbeddings(ctx context.Context, params openai.EmbeddingNewParams) (*openai.CreateEmbeddingResponse, error) {
+	return provider.client.Embeddings.New(ctx, params)
+}
+
+// WithChatProvider sets the provider of the chat completions (default: the OpenAI client of WithDMR, WithOpenAI...).
+// It is useful to use another API, or a fake model in the unit tests.
+// It wins over WithDMR, WithOpenAI, WithOpenAIURL and WithOllama, whatever the order of the options.
+func WithChatProvider(provider ChatProvider) AgentOption {
+	return func(agent *Agent) {
+		agent.explicitChatProvider = provider
+	}
+}
+
+// WithEmbeddingProvider sets the provider of the embeddings (default: the OpenAI client of WithDMR, WithOpenAI...).
+// It wins over WithDMR, WithOpenAI, WithOpenAIURL and WithOllama, whatever the order of the options.
+func WithEmbeddingProvider(provider EmbeddingProvider) AgentOption {
+	return func(agent *Agent) {
+		agent.explicitEmbeddingProvider = provider
+	}
+}
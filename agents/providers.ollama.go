@@ -0,0 +1,406 @@
+package agents
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openai/openai-go"
+)
+
+// DefaultOllamaURL is the default URL of the Ollama API.
+const DefaultOllamaURL = "http://localhost:11434"
+
+// OllamaProvider uses the native API of Ollama (/api/chat and /api/embed).
+// It converts the OpenAI requests and responses of the agent: messages (text and base64 images), tools,
+// tool calls, response format and the main options (temperature, top_p, seed, max tokens, stop).
+// It is both a ChatProvider and an EmbeddingProvider.
+type OllamaProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOllamaProvider creates an Ollama provider (baseURL default: DefaultOllamaURL).
+func NewOllamaProvider(baseURL string) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = DefaultOllamaURL
+	}
+	return &OllamaProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+// WithOllama uses the native API of Ollama for the chat completions and the embeddings of the agent.
+func WithOllama(baseURL string) AgentOption {
+	return func(agent *Agent) {
+		provider := NewOllamaProvider(baseURL)
+		agent.chatProvider = provider
+		agent.embeddingProvider = provider
+	}
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    json.RawMessage `json:"tools,omitempty"`
+	Format   any             `json:"format,omitempty"`
+	Options  map[string]any  `json:"options,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int64         `json:"prompt_eval_count"`
+	EvalCount       int64         `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (provider *OllamaProvider) ChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	response, err := provider.post(ctx, "/api/chat", params, false)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var chatResponse ollamaChatResponse
+	if err := json.NewDecoder(response.Body).Decode(&chatResponse); err != nil {
+		return nil, fmt.Errorf("invalid Ollama response: %w", err)
+	}
+	toolCalls := []openai.ChatCompletionMessageToolCall{}
+	for _, toolCall := range chatResponse.Message.ToolCalls {
+		arguments, _ := json.Marshal(toolCall.Function.Arguments)
+		toolCalls = append(toolCalls, openai.ChatCompletionMessageToolCall{
+			ID:       "call_" + uuid.NewString(),
+			Type:     "function",
+			Function: openai.ChatCompletionMessageToolCallFunction{Name: toolCall.Function.Name, Arguments: string(arguments)},
+		})
+	}
+
+	return &openai.ChatCompletion{
+		ID:      "chatcmpl-" + uuid.NewString(),
+		Object:  "chat.completion",
+		Created: chatResponse.CreatedAt.Unix(),
+		Model:   chatResponse.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Index:        0,
+			FinishReason: ollamaFinishReason(chatResponse, len(toolCalls) > 0),
+			Message: openai.ChatCompletionMessage{
+				Role:      "assistant",
+				Content:   chatResponse.Message.Content,
+				ToolCalls: toolCalls,
+			},
+		}},
+		Usage: openai.CompletionUsage{
+			PromptTokens:     chatResponse.PromptEvalCount,
+			CompletionTokens: chatResponse.EvalCount,
+			TotalTokens:      chatResponse.PromptEvalCount + chatResponse.EvalCount,
+		},
+	}, nil
+}
+
+func (provider *OllamaProvider) ChatCompletionStream(ctx context.Context, params openai.ChatCompletionNewParams, onChunk func(chunk openai.ChatCompletionChunk) error) error {
+	response, err := provider.post(ctx, "/api/chat", params, true)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	id := "chatcmpl-" + uuid.NewString()
+	toolCallIndex := 0
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chatResponse ollamaChatResponse
+		if err := json.Unmarshal(line, &chatResponse); err != nil {
+			return fmt.Errorf("invalid Ollama response: %w", err)
+		}
+		if chatResponse.Error != "" {
+			return errors.New("ollama: " + chatResponse.Error)
+		}
+
+		delta := openai.ChatCompletionChunkChoiceDelta{
+			Role:    "assistant",
+			Content: chatResponse.Message.Content,
+		}
+		// NOTE: Ollama sends the complete tool calls, they are sent as deltas with their index
+		for _, toolCall := range chatResponse.Message.ToolCalls {
+			arguments, _ := json.Marshal(toolCall.Function.Arguments)
+			delta.ToolCalls = append(delta.ToolCalls, openai.ChatCompletionChunkChoiceDeltaToolCall{
+				Index:    int64(toolCallIndex),
+				ID:       "call_" + uuid.NewString(),
+				Type:     "function",
+				Function: openai.ChatCompletionChunkChoiceDeltaToolCallFunction{Name: toolCall.Function.Name, Arguments: string(arguments)},
+			})
+			toolCallIndex++
+		}
+		chunk := openai.ChatCompletionChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: chatResponse.CreatedAt.Unix(),
+			Model:   chatResponse.Model,
+			Choices: []openai.ChatCompletionChunkChoice{{Index: 0, Delta: delta}},
+		}
+		if chatResponse.Done {
+			chunk.Choices[0].FinishReason = ollamaFinishReason(chatResponse, toolCallIndex > 0)
+		}
+		if err := onChunk(chunk); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (provider *OllamaProvider) CreateEmbeddings(ctx context.Context, params openai.EmbeddingNewParams) (*openai.CreateEmbeddingResponse, error) {
+	response, err := provider.post(ctx, "/api/embed", params, false)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var embedResponse struct {
+		Model           string      `json:"model"`
+		Embeddings      [][]float64 `json:"embeddings"`
+		PromptEvalCount int64       `json:"prompt_eval_count"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&embedResponse); err != nil {
+		return nil, fmt.Errorf("invalid Ollama response: %w", err)
+	}
+	embeddings := &openai.CreateEmbeddingResponse{
+		Object: "list",
+		Model:  embedResponse.Model,
+		Usage: openai.CreateEmbeddingResponseUsage{
+			PromptTokens: embedResponse.PromptEvalCount,
+			TotalTokens:  embedResponse.PromptEvalCount,
+		},
+	}
+	for i, embedding := range embedResponse.Embeddings {
+		embeddings.Data = append(embeddings.Data, openai.Embedding{Object: "embedding", Index: int64(i), Embedding: embedding})
+	}
+	return embeddings, nil
+}
+
+// post sends the OpenAI parameters (chat or embeddings), converted to the Ollama API, and checks the status of the response.
+func (provider *OllamaProvider) post(ctx context.Context, path string, params any, stream bool) (*http.Response, error) {
+	var request any
+	var err error
+	switch params := params.(type) {
+	case openai.ChatCompletionNewParams:
+		request, err = ollamaChatRequestOf(params, stream)
+	case openai.EmbeddingNewParams:
+		request, err = ollamaEmbedRequestOf(params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	response, err := provider.client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(response.Body)
+		if json.Unmarshal(data, &errorResponse) != nil || errorResponse.Error == "" {
+			errorResponse.Error = strings.TrimSpace(string(data))
+		}
+		return nil, fmt.Errorf("ollama: %s: %s", response.Status, errorResponse.Error)
+	}
+	return response, nil
+}
+
+// ollamaChatRequestOf converts the OpenAI chat parameters, through their JSON representation.
+func ollamaChatRequestOf(params openai.ChatCompletionNewParams, stream bool) (ollamaChatRequest, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return ollamaChatRequest{}, err
+	}
+	var openAIRequest struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role       string          `json:"role"`
+			Content    json.RawMessage `json:"content"`
+			ToolCallID string          `json:"tool_call_id"`
+			ToolCalls  []struct {
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+		Tools          json.RawMessage `json:"tools"`
+		ResponseFormat *struct {
+			Type       string `json:"type"`
+			JSONSchema struct {
+				Schema any `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if err := json.Unmarshal(data, &openAIRequest); err != nil {
+		return ollamaChatRequest{}, err
+	}
+
+	request := ollamaChatRequest{
+		Model:   openAIRequest.Model,
+		Options: ollamaOptionsOf(data),
+		Stream:  stream,
+	}
+	if len(openAIRequest.Tools) > 0 && string(openAIRequest.Tools) != "null" {
+		request.Tools = openAIRequest.Tools
+	}
+	if format := openAIRequest.ResponseFormat; format != nil {
+		switch format.Type {
+		case "json_schema":
+			request.Format = format.JSONSchema.Schema
+		case "json_object":
+			request.Format = "json"
+		}
+	}
+
+	// NOTE: the tool messages of Ollama give the name of the tool, not the id of the tool call
+	toolNames := map[string]string{}
+	for _, openAIMessage := range openAIRequest.Messages {
+		message := ollamaMessage{Role: openAIMessage.Role}
+		if message.Role == "developer" {
+			message.Role = "system"
+		}
+		message.Content, message.Images = ollamaContentOf(openAIMessage.Content)
+		for _, toolCall := range openAIMessage.ToolCalls {
+			toolNames[toolCall.ID] = toolCall.Function.Name
+			ollamaCall := ollamaToolCall{}
+			ollamaCall.Function.Name = toolCall.Function.Name
+			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &ollamaCall.Function.Arguments); err != nil || ollamaCall.Function.Arguments == nil {
+				ollamaCall.Function.Arguments = map[string]any{}
+			}
+			message.ToolCalls = append(message.ToolCalls, ollamaCall)
+		}
+		if message.Role == "tool" {
+			message.ToolName = toolNames[openAIMessage.ToolCallID]
+		}
+		request.Messages = append(request.Messages, message)
+	}
+	return request, nil
+}
+
+// ollamaContentOf returns the text and the base64 images (data URLs) of an OpenAI message content (string or parts).
+func ollamaContentOf(content json.RawMessage) (string, []string) {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		return text, nil
+	}
+	var parts []struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		ImageURL struct {
+			URL string `json:"url"`
+		} `json:"image_url"`
+	}
+	json.Unmarshal(content, &parts)
+	texts := []string{}
+	var images []string
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			texts = append(texts, part.Text)
+		case "image_url":
+			if _, data, ok := strings.Cut(part.ImageURL.URL, ";base64,"); ok {
+				images = append(images, data)
+			}
+		}
+	}
+	return strings.Join(texts, "\n"), images
+}
+
+// ollamaOptionsOf returns the Ollama options of the OpenAI parameters (JSON).
+func ollamaOptionsOf(data []byte) map[string]any {
+	var params map[string]any
+	json.Unmarshal(data, &params)
+	options := map[string]any{}
+	for openAIName, ollamaName := range map[string]string{
+		"temperature":           "temperature",
+		"top_p":                 "top_p",
+		"seed":                  "seed",
+		"stop":                  "stop",
+		"frequency_penalty":     "frequency_penalty",
+		"presence_penalty":      "presence_penalty",
+		"max_tokens":            "num_predict",
+		"max_completion_tokens": "num_predict",
+	} {
+		if value, ok := params[openAIName]; ok && value != nil {
+			options[ollamaName] = value
+		}
+	}
+	if stop, ok := options["stop"].(string); ok {
+		options["stop"] = []string{stop}
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// ollamaEmbedRequestOf converts the OpenAI embedding parameters (the input is a string or a list of strings).
+func ollamaEmbedRequestOf(params openai.EmbeddingNewParams) (map[string]any, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var request map[string]any
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+	return map[string]any{"model": request["model"], "input": request["input"]}, nil
+}
+
+func ollamaFinishReason(response ollamaChatResponse, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	if response.DoneReason != "" {
+		return response.DoneReason
+	}
+	return "stop"
+}
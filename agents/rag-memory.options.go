@@ -32,7 +32,7 @@ func WithRAGMemory(ctx context.Context, chunks []string) AgentOption {
 			agent.EmbeddingParams.Input = openai.EmbeddingNewParamsInputUnion{
 				OfString: openai.String(chunk),
 			}
-			embeddingsResponse, err := agent.clientEngine.Embeddings.New(ctx, agent.EmbeddingParams)
+			embeddingsResponse, err := agent.embeddingProvider.CreateEmbeddings(ctx, agent.EmbeddingParams)
 
 			if err != nil {
 				agent.optionError = fmt.Errorf("failed to create embedding for chunk: %w", err)
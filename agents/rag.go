@@ -21,7 +21,7 @@ func (agent *Agent) RAGMemorySearchSimilaritiesWithText(ctx context.Context, tex
 	agent.EmbeddingParams.Input = openai.EmbeddingNewParamsInputUnion{
 		OfString: openai.String(text),
 	}
-	embeddingResponse, err := agent.clientEngine.Embeddings.New(ctx, agent.EmbeddingParams)
+	embeddingResponse, err := agent.embeddingProvider.CreateEmbeddings(ctx, agent.EmbeddingParams)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +51,7 @@ func (agent *Agent) RAGMemorySearchSimilaritiesWithText(ctx context.Context, tex
 func (agent *Agent) RAGMemorySearchSimilaritiesWith(ctx context.Context, embedding openai.EmbeddingNewParamsInputUnion, limit float64) ([]string, error) {
 	// Create the embedding from the question
 	agent.EmbeddingParams.Input = embedding
-	embeddingResponse, err := agent.clientEngine.Embeddings.New(ctx, agent.EmbeddingParams)
+	embeddingResponse, err := agent.embeddingProvider.CreateEmbeddings(ctx, agent.EmbeddingParams)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +78,7 @@ func (agent *Agent) CreateEmbeddingFromText(ctx context.Context, text string) (o
 	agent.EmbeddingParams.Input = openai.EmbeddingNewParamsInputUnion{
 		OfString: openai.String(text),
 	}
-	embeddingResponse, err := agent.clientEngine.Embeddings.New(ctx, agent.EmbeddingParams)
+	embeddingResponse, err := agent.embeddingProvider.CreateEmbeddings(ctx, agent.EmbeddingParams)
 	if err != nil {
 		return openai.Embedding{}, err
 	}
@@ -0,0 +1,68 @@
+package agents
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// CompletionIDHeader is the response header carrying the ID of the completion of an HTTP request.
+// The ID is used to cancel the completion with the cancel endpoint.
+const CompletionIDHeader = "X-Completion-ID"
+
+// httpCompletions keeps the cancel functions of the running completions of the HTTP server.
+type httpCompletions struct {
+	mutex   sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newHTTPCompletions() *httpCompletions {
+	return &httpCompletions{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// start registers a new completion and returns its ID and its context.
+// The returned function must be called when the completion is done.
+func (completions *httpCompletions) start(parent context.Context) (string, context.Context, func()) {
+	id := uuid.New().String()
+	ctx, done := completions.startWithID(id, parent)
+	return id, ctx, done
+}
+
+// startWithID registers a new completion with the given ID (e.g. the ID of an A2A task).
+func (completions *httpCompletions) startWithID(id string, parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	completions.mutex.Lock()
+	completions.cancels[id] = cancel
+	completions.mutex.Unlock()
+
+	return ctx, func() {
+		completions.mutex.Lock()
+		delete(completions.cancels, id)
+		completions.mutex.Unlock()
+		cancel()
+	}
+}
+
+// cancel stops the completion with the given ID. It returns false if the completion is not running.
+func (completions *httpCompletions) cancel(id string) bool {
+	completions.mutex.Lock()
+	cancel, ok := completions.cancels[id]
+	completions.mutex.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// cancelAll stops all the running completions.
+func (completions *httpCompletions) cancelAll() {
+	completions.mutex.Lock()
+	defer completions.mutex.Unlock()
+	for _, cancel := range completions.cancels {
+		cancel()
+	}
+}
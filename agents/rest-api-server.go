@@ -1,21 +1,39 @@
 package agents
 
 import (
+	"errors"
 	"net/http"
 )
 
+// StartHttpServer starts the REST API server and blocks until it stops.
 func (agent *Agent) StartHttpServer() error {
+	agentServer, err := agent.StartHttpServerAsync()
+	if err != nil {
+		return err
+	}
+	return agentServer.Wait()
+}
 
-	errListening := http.ListenAndServe(":"+agent.httpServerConfig.Port, agent.httpServer)
-
-	return errListening
+// StartHttpServerAsync starts the REST API server in the background and returns it.
+// The running completions are cancelled when the graceful shutdown of the server times out.
+func (agent *Agent) StartHttpServerAsync() (*AgentServer, error) {
+	if agent.httpServer == nil {
+		return nil, errors.New("the HTTP server is not configured (WithHTTPServer)")
+	}
+	return agent.startServer(":"+agent.httpServerConfig.Port, agent.HttpHandler(), agent.httpCompletions.cancelAll, nil)
 }
 
 func (agent *Agent) HttpServer() *http.ServeMux {
 	return agent.httpServer
 }
+
+// HttpHandler returns the handler of the REST API server with the security of the agent (e.g. for httptest).
+func (agent *Agent) HttpHandler() http.Handler {
+	return agent.secureHandler(agent.httpServer)
+}
+
 // TODO: perhaps add a helper more straightforward
 
 func (agent *Agent) HttpServerConfig() HTTPServerConfig {
 	return agent.httpServerConfig
-}
\ No newline at end of file
+}
@@ -0,0 +1,157 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openai/openai-go"
+)
+
+// OpenAI-compatible endpoints of the HTTP server (HTTPServerConfig.OpenAICompatible).
+const (
+	OpenAIChatCompletionsEndpoint = "/v1/chat/completions"
+	OpenAIModelsEndpoint          = "/v1/models"
+)
+
+// OpenAIChatCompletionRequest is the body of the OpenAI-compatible chat completions endpoint.
+// Only the messages and the stream flag are used: the model and the other parameters are the agent's ones.
+type OpenAIChatCompletionRequest struct {
+	Model    string                                   `json:"model,omitempty"`
+	Messages []openai.ChatCompletionMessageParamUnion `json:"messages"`
+	Stream   bool                                     `json:"stream,omitempty"`
+}
+
+type openAICompletionMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type openAICompletionChoice struct {
+	Index        int                      `json:"index"`
+	Message      *openAICompletionMessage `json:"message,omitempty"`
+	Delta        *openAICompletionMessage `json:"delta,omitempty"`
+	FinishReason *string                  `json:"finish_reason"`
+}
+
+type openAICompletion struct {
+	ID      string                   `json:"id"`
+	Object  string                   `json:"object"`
+	Created int64                    `json:"created"`
+	Model   string                   `json:"model"`
+	Choices []openAICompletionChoice `json:"choices"`
+}
+
+// handleOpenAICompatibleAPI registers the OpenAI-compatible endpoints:
+//   - POST OpenAIChatCompletionsEndpoint: chat completion (stream: true for the chunks as Server-Sent Events)
+//   - GET OpenAIModelsEndpoint: the model of the agent
+//
+// The endpoints are stateless: the conversation is the agent's messages (e.g. the system instructions)
+// followed by the messages of the request.
+// When the agent has tools, the agent executes the tool calls (Run, RunStream) and only the final answer is returned.
+func (agent *Agent) handleOpenAICompatibleAPI() {
+
+	agent.httpServer.HandleFunc("POST "+OpenAIChatCompletionsEndpoint, func(response http.ResponseWriter, request *http.Request) {
+		var data OpenAIChatCompletionRequest
+		body, err := GetBytesBody(request)
+		if err != nil {
+			writeOpenAIError(response, requestBodyErrorStatus(err), "invalid_request_error", err)
+			return
+		}
+		if err := json.Unmarshal(body, &data); err != nil {
+			writeOpenAIError(response, http.StatusBadRequest, "invalid_request_error", err)
+			return
+		}
+		if len(data.Messages) == 0 {
+			writeOpenAIError(response, http.StatusBadRequest, "invalid_request_error", errors.New("the messages are empty"))
+			return
+		}
+
+		completionID, ctx, done := agent.httpCompletions.start(request.Context())
+		defer done()
+		response.Header().Set(CompletionIDHeader, completionID)
+
+		requestAgent := agent.withMessages(append(
+			append([]openai.ChatCompletionMessageParamUnion{}, agent.Params.Messages...),
+			data.Messages...,
+		))
+
+		completion := openAICompletion{
+			ID:      "chatcmpl-" + uuid.NewString(),
+			Created: time.Now().Unix(),
+			Model:   agent.Params.Model,
+		}
+		stop := "stop"
+
+		if !data.Stream {
+			answer, err := requestAgent.answer(ctx, nil)
+			if err != nil {
+				writeOpenAIError(response, http.StatusInternalServerError, "server_error", err)
+				return
+			}
+			completion.Object = "chat.completion"
+			completion.Choices = []openAICompletionChoice{{
+				Message:      &openAICompletionMessage{Role: "assistant", Content: answer},
+				FinishReason: &stop,
+			}}
+			response.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(response).Encode(completion)
+			return
+		}
+
+		flusher, ok := response.(http.Flusher)
+		if !ok {
+			writeOpenAIError(response, http.StatusInternalServerError, "server_error", errors.New("expected http.ResponseWriter to be an http.Flusher"))
+			return
+		}
+		response.Header().Set("Content-Type", "text/event-stream")
+		response.Header().Set("Cache-Control", "no-cache")
+		response.Header().Set("Connection", "keep-alive")
+
+		completion.Object = "chat.completion.chunk"
+		completion.Choices = []openAICompletionChoice{{Delta: &openAICompletionMessage{Role: "assistant"}}}
+		writeSSEEvent(response, flusher, "", completion)
+
+		_, err = requestAgent.answer(ctx, func(self *Agent, content string, err error) error {
+			if content != "" {
+				completion.Choices = []openAICompletionChoice{{Delta: &openAICompletionMessage{Content: content}}}
+				writeSSEEvent(response, flusher, "", completion)
+			}
+			return ctx.Err()
+		})
+		if err != nil {
+			writeSSEEvent(response, flusher, "", map[string]any{
+				"error": map[string]string{"message": err.Error(), "type": "server_error"},
+			})
+		} else {
+			completion.Choices = []openAICompletionChoice{{Delta: &openAICompletionMessage{}, FinishReason: &stop}}
+			writeSSEEvent(response, flusher, "", completion)
+		}
+		response.Write([]byte("data: [DONE]\n\n"))
+		flusher.Flush()
+	})
+
+	agent.httpServer.HandleFunc("GET "+OpenAIModelsEndpoint, func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(response).Encode(map[string]any{
+			"object": "list",
+			"data": []map[string]any{{
+				"id":       agent.Params.Model,
+				"object":   "model",
+				"created":  0,
+				"owned_by": agent.Name,
+			}},
+		})
+	})
+}
+
+// writeOpenAIError writes an error with the format of the OpenAI API.
+func writeOpenAIError(response http.ResponseWriter, status int, errorType string, err error) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(status)
+	json.NewEncoder(response).Encode(map[string]any{
+		"error": map[string]string{"message": err.Error(), "type": errorType},
+	})
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/openai/openai-go"
 )
 
@@ -13,20 +16,58 @@ type HTTPServerConfig struct {
 	Port           string
 	Endpoint       string
 	StreamEndPoint string
+
+	// Sessions: each session has its own conversation with the agent
+	SessionHeader    string        // Header carrying the session ID (default: "X-Session-ID"), it can also be set with "session_id" in the JSON body
+	SessionTTL       time.Duration // Sessions not used for this duration are evicted (default: 30 minutes, negative: never)
+	SessionsEndpoint string        // Endpoint to list (authenticated servers only), reset and delete the sessions (default: "/api/sessions")
+
+	CancelEndpoint string // Endpoint to cancel a running completion (default: "/api/completion/cancel")
+
+	UseSSE bool // Stream the completions as Server-Sent Events (also enabled by the "Accept: text/event-stream" request header)
+
+	// OpenAICompatible adds the OpenAI-compatible endpoints OpenAIChatCompletionsEndpoint and OpenAIModelsEndpoint
+	// so the OpenAI SDKs and UIs can talk to the agent.
+	OpenAICompatible bool
 }
 
-func GetBytesBody(request *http.Request) []byte {
-	body := make([]byte, request.ContentLength)
-	request.Body.Read(body)
-	return body
+// HTTPChatRequest is the JSON body of the chat endpoints.
+type HTTPChatRequest struct {
+	User      string `json:"user"`
+	SessionID string `json:"session_id,omitempty"`
 }
+
+// GetBytesBody reads the body of the request.
+// When the body exceeds the size limit of the server (MaxRequestBodyBytes, see WithServerSecurity),
+// the error is a *http.MaxBytesError (see requestBodyErrorStatus).
+func GetBytesBody(request *http.Request) ([]byte, error) {
+	return io.ReadAll(request.Body)
+}
+
 // WithHTTPServer configures the HTTP server for the agent.
 // It sets up the server with default endpoints and port if not provided.
 // The server handles POST requests for chat completions and streams.
-// It also provides a mechanism to cancel ongoing completions via a DELETE request (commented out).
+// It also provides a mechanism to cancel ongoing completions via a DELETE request (CancelEndpoint).
 // The server uses the provided HTTPServerConfig to configure its behavior.
 // If the StreamEndPoint or Endpoint is not specified, it defaults to "/api/chat-stream" and "/api/chat" respectively.
 // The default port is set to "8888" if not specified.
+//
+// Each HTTP client gets its own conversation, identified by a session ID (SessionHeader header or "session_id" in the JSON body).
+// A request without session ID starts a new session with a generated ID: the client must send it back to continue the conversation.
+// The session ID is always sent in the SessionHeader response header.
+// A new session starts with the messages of the agent (e.g. the system instructions), the agent's messages are never modified.
+// The sessions endpoint provides:
+//   - GET {SessionsEndpoint}: list the sessions (only when the server requires an authentication, see WithServerSecurity)
+//   - POST {SessionsEndpoint}/{id}/reset: reset the conversation of a session
+//   - DELETE {SessionsEndpoint}/{id}: delete a session
+//
+// NOTE: the session ID gives access to the conversation: use the generated IDs (random), not guessable ones.
+//
+// Each completion gets an ID, sent in the CompletionIDHeader response header.
+// A running completion is stopped with DELETE {CancelEndpoint}?id={completion id}, or when the client disconnects.
+//
+// The stream endpoint writes raw text chunks, or Server-Sent Events (data: {"content": "..."}, event: error, data: [DONE])
+// when UseSSE is set or when the client sends the "Accept: text/event-stream" header.
 func WithHTTPServer(httpServerConfig HTTPServerConfig) AgentOption {
 	return func(agent *Agent) {
 		agent.httpServerConfig = httpServerConfig
@@ -46,11 +87,28 @@ func WithHTTPServer(httpServerConfig HTTPServerConfig) AgentOption {
 			agent.httpServerConfig.Port = "8888"
 		}
 
+		if httpServerConfig.SessionHeader == "" {
+			agent.httpServerConfig.SessionHeader = "X-Session-ID"
+		}
+
+		if httpServerConfig.SessionTTL == 0 {
+			agent.httpServerConfig.SessionTTL = 30 * time.Minute
+		}
+
+		if httpServerConfig.SessionsEndpoint == "" {
+			agent.httpServerConfig.SessionsEndpoint = "/api/sessions"
+		}
+
+		if httpServerConfig.CancelEndpoint == "" {
+			agent.httpServerConfig.CancelEndpoint = "/api/completion/cancel"
+		}
+
+		agent.httpSessions = newHTTPSessionStore(agent.httpServerConfig.SessionTTL)
+		agent.httpCompletions = newHTTPCompletions()
+
 		// Create HTTP server
 		agent.httpServer = http.NewServeMux()
 
-		shouldIStopTheCompletion := false
-
 		// Streaming endpoint
 		agent.httpServer.HandleFunc("POST "+agent.httpServerConfig.StreamEndPoint, func(response http.ResponseWriter, request *http.Request) {
 
@@ -58,86 +116,181 @@ func WithHTTPServer(httpServerConfig HTTPServerConfig) AgentOption {
 			flusher, ok := response.(http.Flusher)
 			if !ok {
 				response.Write([]byte("Error: expected http.ResponseWriter to be an http.Flusher"))
+				return
 			}
-			body := GetBytesBody(request)
-			// unmarshal the json data
-			var data map[string]string
-			err := json.Unmarshal(body, &data)
+
+			data, err := decodeHTTPChatRequest(request)
 			if err != nil {
-				response.Write([]byte("Error: " + err.Error()))
+				http.Error(response, "Error: "+err.Error(), requestBodyErrorStatus(err))
+				return
 			}
 
-			//systemContent := data["system"]
-			userContent := data["user"]
+			// NOTE: the completion stops when the client disconnects or when it is cancelled
+			completionID, ctx, done := agent.httpCompletions.start(request.Context())
+			defer done()
+			response.Header().Set(CompletionIDHeader, completionID)
 
-			agent.Params.Messages = append(
-				agent.Params.Messages, openai.UserMessage(userContent),
+			session := agent.httpSession(response, request, data)
+			writer := agent.newStreamWriter(response, request, flusher)
+			flusher.Flush()
+			session.mutex.Lock()
+			defer session.mutex.Unlock()
+
+			sessionAgent := agent.withMessages(
+				append(agent.httpSessions.messages(session), openai.UserMessage(data.User)),
 			)
 
-			answer, err := agent.ChatCompletionStream(context.Background(), func(self *Agent, content string, err error) error {
-				response.Write([]byte(content))
-
-				flusher.Flush()
-				if !shouldIStopTheCompletion {
-					return nil
-				} else {
-					return errors.New("cancelling request")
-				}
-				//return nil
-			})
-			if err != nil {
-				response.Write([]byte("Error: " + err.Error()))
-				return
+			streamChunk := func(self *Agent, content string, err error) error {
+				writer.chunk(content)
+				return ctx.Err()
 			}
 
-			agent.Params.Messages = append(
-				agent.Params.Messages, openai.AssistantMessage(answer),
-			)
+			_, err = sessionAgent.answer(ctx, streamChunk)
+			agent.httpSessions.setMessages(session, sessionAgent.Params.Messages)
+			if err != nil {
+				writer.fail(err)
+			}
+			writer.done()
 
 		})
 
-		/* TODO:
 		// Cancel/Stop the generation of the completion
-		mux.HandleFunc("DELETE /api/completion/cancel", func(response http.ResponseWriter, request *http.Request) {
-			shouldIStopTheCompletion = true
-			response.Write([]byte("cancelling request..."))
+		agent.httpServer.HandleFunc("DELETE "+agent.httpServerConfig.CancelEndpoint, func(response http.ResponseWriter, request *http.Request) {
+			completionID := request.URL.Query().Get("id")
+			if completionID == "" {
+				completionID = request.Header.Get(CompletionIDHeader)
+			}
+			if completionID == "" {
+				http.Error(response, "Error: the completion id is missing", http.StatusBadRequest)
+				return
+			}
+			if !agent.httpCompletions.cancel(completionID) {
+				http.Error(response, "Error: completion not found", http.StatusNotFound)
+				return
+			}
+			response.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(response).Encode(map[string]string{"id": completionID, "status": "cancelling"})
 		})
-		*/
 
 		// Non streaming endpoint
 		agent.httpServer.HandleFunc("POST "+agent.httpServerConfig.Endpoint, func(response http.ResponseWriter, request *http.Request) {
 
-
-			body := GetBytesBody(request)
-			// unmarshal the json data
-			var data map[string]string
-			err := json.Unmarshal(body, &data)
+			data, err := decodeHTTPChatRequest(request)
 			if err != nil {
-				response.Write([]byte("Error: " + err.Error()))
+				http.Error(response, "Error: "+err.Error(), requestBodyErrorStatus(err))
+				return
 			}
 
-			//systemContent := data["system"]
-			userContent := data["user"]
-			// TODO: add a check about user
+			completionID, ctx, done := agent.httpCompletions.start(request.Context())
+			defer done()
+			response.Header().Set(CompletionIDHeader, completionID)
 
-			agent.Params.Messages = append(
-				agent.Params.Messages, openai.UserMessage(userContent),
+			session := agent.httpSession(response, request, data)
+			session.mutex.Lock()
+			defer session.mutex.Unlock()
+
+			sessionAgent := agent.withMessages(
+				append(agent.httpSessions.messages(session), openai.UserMessage(data.User)),
 			)
 
-			answer, err := agent.ChatCompletion(context.Background())
+			answer, err := sessionAgent.answer(ctx, nil)
+			agent.httpSessions.setMessages(session, sessionAgent.Params.Messages)
 			if err != nil {
 				response.Write([]byte("Error: " + err.Error()))
 				return
 			}
 			response.Write([]byte(answer))
+		})
 
-			agent.Params.Messages = append(
-				agent.Params.Messages, openai.AssistantMessage(answer),
-			)
+		// Sessions endpoints
+		agent.httpServer.HandleFunc("GET "+agent.httpServerConfig.SessionsEndpoint, func(response http.ResponseWriter, request *http.Request) {
+			// NOTE: the IDs give access to the conversations, they are only listed for the authenticated clients
+			if !agent.serverSecurity.authenticationRequired() || !agent.serverSecurity.authorized(request) {
+				http.Error(response, "Error: listing the sessions requires an authentication", http.StatusForbidden)
+				return
+			}
+			response.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(response).Encode(agent.httpSessions.list())
 		})
 
+		agent.httpServer.HandleFunc("POST "+agent.httpServerConfig.SessionsEndpoint+"/{id}/reset", func(response http.ResponseWriter, request *http.Request) {
+			if !agent.httpSessions.reset(request.PathValue("id"), agent.Params.Messages) {
+				http.Error(response, "Error: session not found", http.StatusNotFound)
+				return
+			}
+			response.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(response).Encode(map[string]string{"id": request.PathValue("id"), "status": "reset"})
+		})
+
+		agent.httpServer.HandleFunc("DELETE "+agent.httpServerConfig.SessionsEndpoint+"/{id}", func(response http.ResponseWriter, request *http.Request) {
+			if !agent.httpSessions.delete(request.PathValue("id")) {
+				http.Error(response, "Error: session not found", http.StatusNotFound)
+				return
+			}
+			response.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(response).Encode(map[string]string{"id": request.PathValue("id"), "status": "deleted"})
+		})
+
+		if agent.httpServerConfig.OpenAICompatible {
+			agent.handleOpenAICompatibleAPI()
+		}
+
+	}
+}
+
+// answer answers the conversation of the agent (a copy of the agent for a session or a request)
+// and adds the answer to its messages. The answer is streamed to callBack if it is not nil.
+// NOTE: when the agent has tools, the tool calls are executed (Run, RunStream), they are never dropped.
+func (agent *Agent) answer(ctx context.Context, callBack func(self *Agent, content string, err error) error) (string, error) {
+	if len(agent.Params.Tools) > 0 {
+		var result RunResult
+		var err error
+		if callBack != nil {
+			result, err = agent.RunStream(ctx, "", RunOptions{}, callBack)
+		} else {
+			result, err = agent.Run(ctx, "", RunOptions{})
+		}
+		return result.Answer, err
+	}
+
+	var answer string
+	var err error
+	if callBack != nil {
+		answer, err = agent.ChatCompletionStream(ctx, callBack)
+	} else {
+		answer, err = agent.ChatCompletion(ctx)
+	}
+	if err == nil {
+		agent.AddAssistantMessage(answer)
 	}
+	return answer, err
 }
 
-// QUESTION: how to handle the conversational memory
-// TODO: https, authentication token, etc.
+func decodeHTTPChatRequest(request *http.Request) (HTTPChatRequest, error) {
+	var data HTTPChatRequest
+	body, err := GetBytesBody(request)
+	if err != nil {
+		return data, err
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return data, err
+	}
+	if data.User == "" {
+		return data, errors.New("the user message is empty")
+	}
+	return data, nil
+}
+
+// httpSession returns the session of the request (SessionHeader header, then "session_id" in the body),
+// or a new session with a generated ID, and sends its ID back in the SessionHeader header.
+func (agent *Agent) httpSession(response http.ResponseWriter, request *http.Request, data HTTPChatRequest) *httpSession {
+	sessionID := request.Header.Get(agent.httpServerConfig.SessionHeader)
+	if sessionID == "" {
+		sessionID = data.SessionID
+	}
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+	response.Header().Set(agent.httpServerConfig.SessionHeader, sessionID)
+	return agent.httpSessions.get(sessionID, agent.Params.Messages)
+}
@@ -0,0 +1,136 @@
+package agents
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// HTTPSession is the conversation of an HTTP client with the agent.
+type HTTPSession struct {
+	ID         string    `json:"id"`
+	Messages   int       `json:"messages"` // Number of messages in the conversation
+	CreatedAt  time.Time `json:"created_at"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// httpSession holds the isolated message history of a session.
+// Its mutex serializes the completions (and the resets) of the session.
+type httpSession struct {
+	mutex      sync.Mutex
+	id         string
+	messages   []openai.ChatCompletionMessageParamUnion
+	createdAt  time.Time
+	lastAccess time.Time
+}
+
+// httpSessionStore keeps the sessions of the HTTP server.
+// The sessions not accessed for ttl are evicted (ttl <= 0: never).
+// The store mutex protects the sessions map, and the messages and lastAccess fields of the sessions.
+type httpSessionStore struct {
+	mutex    sync.Mutex
+	sessions map[string]*httpSession
+	ttl      time.Duration
+}
+
+func newHTTPSessionStore(ttl time.Duration) *httpSessionStore {
+	return &httpSessionStore{
+		sessions: make(map[string]*httpSession),
+		ttl:      ttl,
+	}
+}
+
+// get returns the session with the given ID, creating it with the initial messages if needed.
+func (store *httpSessionStore) get(id string, initialMessages []openai.ChatCompletionMessageParamUnion) *httpSession {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.evictExpired()
+
+	session, ok := store.sessions[id]
+	if !ok {
+		now := time.Now()
+		session = &httpSession{
+			id:        id,
+			messages:  slices.Clone(initialMessages),
+			createdAt: now,
+		}
+		store.sessions[id] = session
+	}
+	session.lastAccess = time.Now()
+	return session
+}
+
+func (store *httpSessionStore) list() []HTTPSession {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.evictExpired()
+
+	sessions := []HTTPSession{}
+	for _, session := range store.sessions {
+		sessions = append(sessions, HTTPSession{
+			ID:         session.id,
+			Messages:   len(session.messages),
+			CreatedAt:  session.createdAt,
+			LastAccess: session.lastAccess,
+		})
+	}
+	slices.SortFunc(sessions, func(a, b HTTPSession) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return sessions
+}
+
+// reset replaces the history of the session with the initial messages.
+func (store *httpSessionStore) reset(id string, initialMessages []openai.ChatCompletionMessageParamUnion) bool {
+	store.mutex.Lock()
+	session, ok := store.sessions[id]
+	if ok {
+		session.lastAccess = time.Now()
+	}
+	store.mutex.Unlock()
+	if !ok {
+		return false
+	}
+
+	// Wait for the running completion of the session
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	store.setMessages(session, slices.Clone(initialMessages))
+	return true
+}
+
+// messages returns the message history of the session.
+func (store *httpSessionStore) messages(session *httpSession) []openai.ChatCompletionMessageParamUnion {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return slices.Clone(session.messages)
+}
+
+// setMessages replaces the message history of the session.
+func (store *httpSessionStore) setMessages(session *httpSession, messages []openai.ChatCompletionMessageParamUnion) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	session.messages = messages
+}
+
+func (store *httpSessionStore) delete(id string) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	_, ok := store.sessions[id]
+	delete(store.sessions, id)
+	return ok
+}
+
+// evictExpired removes the expired sessions. The store mutex must be held.
+func (store *httpSessionStore) evictExpired() {
+	if store.ttl <= 0 {
+		return
+	}
+	for id, session := range store.sessions {
+		if time.Since(session.lastAccess) > store.ttl {
+			delete(store.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package agents
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// streamWriter writes the chunks, the error and the end of a streamed completion.
+type streamWriter interface {
+	chunk(content string)
+	fail(err error)
+	done()
+}
+
+// newStreamWriter returns a Server-Sent Events writer if SSE is enabled or requested (Accept: text/event-stream),
+// otherwise a raw text writer.
+func (agent *Agent) newStreamWriter(response http.ResponseWriter, request *http.Request, flusher http.Flusher) streamWriter {
+	if agent.httpServerConfig.UseSSE || strings.Contains(request.Header.Get("Accept"), "text/event-stream") {
+		response.Header().Set("Content-Type", "text/event-stream")
+		response.Header().Set("Cache-Control", "no-cache")
+		response.Header().Set("Connection", "keep-alive")
+		return &sseStreamWriter{response: response, flusher: flusher}
+	}
+	return &rawStreamWriter{response: response, flusher: flusher}
+}
+
+// rawStreamWriter writes the chunks as plain text (the historical format of the stream endpoint).
+type rawStreamWriter struct {
+	response http.ResponseWriter
+	flusher  http.Flusher
+}
+
+func (writer *rawStreamWriter) chunk(content string) {
+	writer.response.Write([]byte(content))
+	writer.flusher.Flush()
+}
+
+func (writer *rawStreamWriter) fail(err error) {
+	writer.response.Write([]byte("Error: " + err.Error()))
+	writer.flusher.Flush()
+}
+
+func (writer *rawStreamWriter) done() {}
+
+// sseStreamWriter writes the chunks as Server-Sent Events:
+//
+//	data: {"content":"..."}
+//
+//	event: error
+//	data: {"error":"..."}
+//
+//	data: [DONE]
+type sseStreamWriter struct {
+	response http.ResponseWriter
+	flusher  http.Flusher
+}
+
+func (writer *sseStreamWriter) chunk(content string) {
+	writer.event("", map[string]string{"content": content})
+}
+
+func (writer *sseStreamWriter) fail(err error) {
+	writer.event("error", map[string]string{"error": err.Error()})
+}
+
+func (writer *sseStreamWriter) done() {
+	writer.response.Write([]byte("data: [DONE]\n\n"))
+	writer.flusher.Flush()
+}
+
+func (writer *sseStreamWriter) event(name string, data any) {
+	writeSSEEvent(writer.response, writer.flusher, name, data)
+}
+
+// writeSSEEvent writes a Server-Sent Event with a JSON payload.
+func writeSSEEvent(response http.ResponseWriter, flusher http.Flusher, name string, data any) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if name != "" {
+		response.Write([]byte("event: " + name + "\n"))
+	}
+	response.Write([]byte("data: " + string(jsonData) + "\n\n"))
+	flusher.Flush()
+}
@@ -0,0 +1,381 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/budgies-nest/budgie/agents/agentstest"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+func postChat(t *testing.T, url string, sessionID string, body string) (string, *http.Response) {
+	request, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		request.Header.Set("X-Session-ID", sessionID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("😡 Failed to call %s: %v", url, err)
+	}
+	defer response.Body.Close()
+	content, _ := io.ReadAll(response.Body)
+	return string(content), response
+}
+
+// go test -v -run TestHTTPServerSessions
+func TestHTTPServerSessions(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.Text("Hello Alice"),
+		agentstest.Text("Hello Bob"),
+		agentstest.Text("Your name is Alice"),
+	)
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithParams(openai.ChatCompletionNewParams{
+			Model: "test",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage("You are Bob"),
+			},
+		}),
+		WithHTTPServer(HTTPServerConfig{}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.HttpServer())
+	defer server.Close()
+
+	answer, response := postChat(t, server.URL+"/api/chat", "alice", `{"user": "I am Alice"}`)
+	if answer != "Hello Alice" || response.Header.Get("X-Session-ID") != "alice" {
+		t.Errorf("😡 Unexpected answer: %s", answer)
+	}
+	postChat(t, server.URL+"/api/chat", "", `{"user": "I am Bob", "session_id": "bob"}`)
+	postChat(t, server.URL+"/api/chat", "alice", `{"user": "What is my name?"}`)
+
+	// The third request only contains the conversation of Alice
+	messages := model.ChatRequests()[2].Messages
+	if len(messages) != 4 {
+		t.Fatalf("😡 Expected 4 messages, got %d", len(messages))
+	}
+	for _, message := range messages {
+		if message.Content == "I am Bob" {
+			t.Errorf("😡 The conversation of Bob leaked into the session of Alice")
+		}
+	}
+	if len(bob.Params.Messages) != 1 {
+		t.Errorf("😡 Expected the agent's messages to be unchanged, got %d", len(bob.Params.Messages))
+	}
+
+	// The sessions are only listed for the authenticated clients
+	response, err = http.Get(server.URL + "/api/sessions")
+	if err != nil {
+		t.Fatalf("😡 Failed to list the sessions: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("😡 Expected the sessions list to require an authentication, got %s", response.Status)
+	}
+
+	bob.serverSecurity = ServerSecurityConfig{BearerTokens: []string{"secret"}}
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/api/sessions", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("😡 Failed to list the sessions: %v", err)
+	}
+	var sessions []HTTPSession
+	json.NewDecoder(response.Body).Decode(&sessions)
+	response.Body.Close()
+	if len(sessions) != 2 || sessions[0].ID != "alice" || sessions[0].Messages != 5 {
+		t.Errorf("😡 Unexpected sessions: %+v", sessions)
+	}
+
+	// Reset and delete the sessions
+	response, _ = http.Post(server.URL+"/api/sessions/alice/reset", "application/json", nil)
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("😡 Failed to reset the session: %s", response.Status)
+	}
+	request, _ = http.NewRequest(http.MethodDelete, server.URL+"/api/sessions/bob", nil)
+	response, _ = http.DefaultClient.Do(request)
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("😡 Failed to delete the session: %s", response.Status)
+	}
+	response, _ = http.DefaultClient.Do(request)
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("😡 Expected the session to be deleted: %s", response.Status)
+	}
+
+	sessions = bob.httpSessions.list()
+	if len(sessions) != 1 || sessions[0].Messages != 1 {
+		t.Errorf("😡 Unexpected sessions after reset: %+v", sessions)
+	}
+}
+
+// go test -v -run TestHTTPServerGeneratedSessions
+func TestHTTPServerGeneratedSessions(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.Text("Hello Alice"),
+		agentstest.Text("Hello Bob"),
+	)
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithModel("test"),
+		WithHTTPServer(HTTPServerConfig{}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.HttpServer())
+	defer server.Close()
+
+	_, first := postChat(t, server.URL+"/api/chat", "", `{"user": "I am Alice"}`)
+	_, second := postChat(t, server.URL+"/api/chat", "", `{"user": "I am Bob"}`)
+	firstID, secondID := first.Header.Get("X-Session-ID"), second.Header.Get("X-Session-ID")
+	if firstID == "" || firstID == secondID {
+		t.Fatalf("😡 Expected a new session for each request without session ID, got %q and %q", firstID, secondID)
+	}
+	// The second request does not contain the conversation of Alice
+	if messages := model.ChatRequests()[1].Messages; len(messages) != 1 {
+		t.Errorf("😡 Expected 1 message, got %d", len(messages))
+	}
+}
+
+// newEndlessModelServer returns a model server streaming a chunk every 10ms until the request is cancelled,
+// and a channel closed when the stream stops.
+func newEndlessModelServer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	modelStopped := make(chan struct{})
+	model := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(modelStopped)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+				w.Write([]byte(`data: {"id":"chunk","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"bla "}}]}` + "\n\n"))
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	t.Cleanup(model.Close)
+	return model, modelStopped
+}
+
+// go test -v -run TestHTTPServerCancelCompletion
+func TestHTTPServerCancelCompletion(t *testing.T) {
+	model, modelStopped := newEndlessModelServer(t)
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithModel("test"),
+		WithHTTPServer(HTTPServerConfig{}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.HttpServer())
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/api/chat-stream", "application/json", strings.NewReader(`{"user": "talk forever"}`))
+	if err != nil {
+		t.Fatalf("😡 Failed to call the stream endpoint: %v", err)
+	}
+	defer response.Body.Close()
+	completionID := response.Header.Get(CompletionIDHeader)
+	if completionID == "" {
+		t.Fatalf("😡 Expected a completion ID")
+	}
+
+	// Read a few chunks, then cancel the completion
+	buffer := make([]byte, 8)
+	response.Body.Read(buffer)
+
+	request, _ := http.NewRequest(http.MethodDelete, server.URL+"/api/completion/cancel?id="+completionID, nil)
+	cancelResponse, err := http.DefaultClient.Do(request)
+	if err != nil || cancelResponse.StatusCode != http.StatusOK {
+		t.Fatalf("😡 Failed to cancel the completion: %v %v", err, cancelResponse.Status)
+	}
+
+	select {
+	case <-modelStopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("😡 Expected the model request to be cancelled")
+	}
+	io.ReadAll(response.Body)
+
+	// The completion is not running anymore
+	cancelResponse, _ = http.DefaultClient.Do(request)
+	if cancelResponse.StatusCode != http.StatusNotFound {
+		t.Errorf("😡 Expected 404, got %s", cancelResponse.Status)
+	}
+}
+
+// go test -v -run TestHTTPServerSSE
+func TestHTTPServerSSE(t *testing.T) {
+	model := agentstest.NewServer(t)
+	model.SetFallback(agentstest.Stream("Hello", " World"))
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithModel("test"),
+		WithHTTPServer(HTTPServerConfig{}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.HttpServer())
+	defer server.Close()
+
+	// Raw text by default
+	answer, _ := postChat(t, server.URL+"/api/chat-stream", "raw", `{"user": "Hello"}`)
+	if answer != "Hello World" {
+		t.Errorf("😡 Unexpected raw stream: %q", answer)
+	}
+
+	// Server-Sent Events with the Accept header
+	request, _ := http.NewRequest(http.MethodPost, server.URL+"/api/chat-stream", strings.NewReader(`{"user": "Hello"}`))
+	request.Header.Set("Accept", "text/event-stream")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("😡 Failed to call the stream endpoint: %v", err)
+	}
+	defer response.Body.Close()
+	content, _ := io.ReadAll(response.Body)
+
+	if response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("😡 Unexpected content type: %s", response.Header.Get("Content-Type"))
+	}
+	expected := "data: {\"content\":\"Hello\"}\n\ndata: {\"content\":\" World\"}\n\ndata: [DONE]\n\n"
+	if !strings.HasSuffix(string(content), expected) {
+		t.Errorf("😡 Unexpected events: %q", string(content))
+	}
+}
+
+// go test -v -run TestHTTPServerOpenAICompatible
+func TestHTTPServerOpenAICompatible(t *testing.T) {
+	model := agentstest.NewServer(t)
+	model.SetFallback(agentstest.Stream("Hello", " World"))
+
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithParams(openai.ChatCompletionNewParams{
+			Model: "test",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage("You are Bob"),
+			},
+		}),
+		WithHTTPServer(HTTPServerConfig{OpenAICompatible: true}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.HttpServer())
+	defer server.Close()
+
+	// Use the OpenAI SDK with the agent
+	client := openai.NewClient(option.WithBaseURL(server.URL+"/v1/"), option.WithAPIKey(""))
+	params := openai.ChatCompletionNewParams{
+		Model:    "bob",
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Hello")},
+	}
+
+	completion, err := client.Chat.Completions.New(context.Background(), params)
+	if err != nil {
+		t.Fatalf("😡 Failed to get the completion: %v", err)
+	}
+	if completion.Choices[0].Message.Content != "Hello World" || completion.Model != "test" {
+		t.Errorf("😡 Unexpected completion: %+v", completion)
+	}
+
+	stream := client.Chat.Completions.NewStreaming(context.Background(), params)
+	answer := ""
+	for stream.Next() {
+		chunk := stream.Current()
+		if len(chunk.Choices) > 0 {
+			answer += chunk.Choices[0].Delta.Content
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("😡 Failed to stream the completion: %v", err)
+	}
+	if answer != "Hello World" {
+		t.Errorf("😡 Unexpected streamed answer: %q", answer)
+	}
+
+	models, err := client.Models.List(context.Background())
+	if err != nil || len(models.Data) != 1 || models.Data[0].ID != "test" {
+		t.Errorf("😡 Unexpected models: %v %+v", err, models)
+	}
+
+	// The endpoint is stateless
+	if len(bob.Params.Messages) != 1 {
+		t.Errorf("😡 Expected the agent's messages to be unchanged, got %d", len(bob.Params.Messages))
+	}
+}
+
+// go test -v -run TestHTTPServerOpenAICompatibleWithTools
+func TestHTTPServerOpenAICompatibleWithTools(t *testing.T) {
+	model := agentstest.NewServer(t,
+		agentstest.ToolCalls(agentstest.Call("add", `{"a":40,"b":2}`)),
+		agentstest.Text("The result is 42"),
+		toolCallsStreamResponse(),
+		agentstest.Stream("The result", " is 42"),
+	)
+
+	type addArgs struct {
+		A float64 `json:"a"`
+		B float64 `json:"b"`
+	}
+	bob, err := NewAgent("Bob",
+		WithDMR(model.URL),
+		WithModel("test"),
+		RegisterTool("add", "add two numbers", func(args addArgs) (float64, error) {
+			return args.A + args.B, nil
+		}),
+		WithHTTPServer(HTTPServerConfig{OpenAICompatible: true}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.HttpServer())
+	defer server.Close()
+
+	client := openai.NewClient(option.WithBaseURL(server.URL+"/v1/"), option.WithAPIKey(""))
+	params := openai.ChatCompletionNewParams{
+		Model:    "bob",
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Add 40 and 2")},
+	}
+	completion, err := client.Chat.Completions.New(context.Background(), params)
+	if err != nil {
+		t.Fatalf("😡 Failed to get the completion: %v", err)
+	}
+	if completion.Choices[0].Message.Content != "The result is 42" {
+		t.Errorf("😡 Expected the answer after the tool call, got %q", completion.Choices[0].Message.Content)
+	}
+
+	stream := client.Chat.Completions.NewStreaming(context.Background(), params)
+	answer := ""
+	for stream.Next() {
+		if chunk := stream.Current(); len(chunk.Choices) > 0 {
+			answer += chunk.Choices[0].Delta.Content
+		}
+	}
+	if err := stream.Err(); err != nil || answer != "Let me compute. The result is 42" {
+		t.Errorf("😡 Expected the streamed answer after the tool call, got %q %v", answer, err)
+	}
+
+	// The tool results ("add" and "ping") are sent back to the model
+	if messages := model.ChatRequests()[3].Messages; len(messages) != 4 {
+		t.Errorf("😡 Expected the tool result to be sent back, got %d messages", len(messages))
+	}
+}
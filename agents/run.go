@@ -0,0 +1,211 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// DefaultRunMaxIterations is the default maximum number of tool calling iterations of Agent.Run.
+const DefaultRunMaxIterations = 10
+
+// RunOptions configures the tool calling loop of Agent.Run.
+type RunOptions struct {
+	// MaxIterations is the maximum number of completions returning tool calls (default: DefaultRunMaxIterations).
+	// When it is reached, the agent is asked for a final answer without tools.
+	MaxIterations int
+	// ToolsImpl holds the implementations of the local function tools (added to the tools registered with RegisterTool).
+	// The MCP tools (WithMCPStdioTools, WithMCPStreamableHttpTools) are executed with the MCP clients of the agent.
+	ToolsImpl map[string]func(any) (any, error)
+	// StopCondition is called after each iteration, once the tool calls are executed.
+	// If it returns true, the loop stops and the agent is asked for a final answer without tools.
+	StopCondition func(step RunStep) bool
+	// Concurrency enables the concurrent execution of the tool calls of an iteration (nil: sequential execution).
+	Concurrency *ConcurrentExecutionConfig
+}
+
+// RunStep describes one iteration of the tool calling loop.
+type RunStep struct {
+	Iteration int
+	ToolCalls []openai.ChatCompletionMessageToolCall
+	Results   []string // Contents of the tool messages, in the same order as ToolCalls
+}
+
+// RunResult is the result of Agent.Run.
+type RunResult struct {
+	Answer               string
+	Steps                []RunStep
+	MaxIterationsReached bool
+	Stopped              bool // True if the StopCondition ended the loop
+}
+
+// Run executes the tool calling loop of the agent:
+// it adds the user input (if not empty) to the messages, detects the tool calls, executes them,
+// feeds the results back to the model, and repeats until the model answers without tool calls.
+// The tool calls are routed to the local implementations (opts.ToolsImpl) or to the MCP clients of the agent.
+// The assistant and tool messages are appended to the Agent's messages, including the final answer.
+// The completions with tools call the tools completion handlers (WithBeforeToolsCompletion, WithAfterToolsCompletion),
+// the final answer requested without tools calls the chat completion handlers.
+func (agent *Agent) Run(ctx context.Context, userInput string, opts RunOptions) (RunResult, error) {
+	return agent.run(ctx, userInput, opts, agent.runCompletion, func(ctx context.Context) (string, error) {
+		return agent.chatCompletion(ctx, completionOptions{skipMemory: true, withoutTools: true})
+	})
+}
+
+// run executes the tool calling loop with the given completions:
+// complete returns the message of the model (tools included), finalAnswer asks for the answer without tools.
+// NOTE: the completions do not apply the conversation memory, run applies it once (see completionOptions).
+func (agent *Agent) run(ctx context.Context, userInput string, opts RunOptions, complete func(ctx context.Context) (openai.ChatCompletionMessage, error), finalAnswer func(ctx context.Context) (string, error)) (RunResult, error) {
+	result := RunResult{}
+
+	maxIterations := opts.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultRunMaxIterations
+	}
+
+	if userInput != "" {
+		agent.AddUserMessage(userInput)
+	}
+	// NOTE: the repair attempts of the invalid tool calls are counted for this Run only
+	agent.toolRepairs.reset()
+
+	// NOTE: the conversation memory is applied once, before the loop:
+	// the assistant and tool messages of the current turn are never trimmed
+	agent.applyConversationMemory(ctx)
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		message, err := complete(ctx)
+		if err != nil {
+			return result, err
+		}
+
+		// NOTE: no tool calls, this is the final answer
+		if len(message.ToolCalls) == 0 {
+			result.Answer = message.Content
+			agent.AddAssistantMessage(message.Content)
+			return result, nil
+		}
+
+		step := RunStep{
+			Iteration: iteration,
+			ToolCalls: message.ToolCalls,
+		}
+		// NOTE: the tool calls are submitted to the tool policy of the agent (WithToolPolicy)
+		step.Results, err = agent.runApprovedToolCalls(ctx, message.ToolCalls, func(toolCalls []openai.ChatCompletionMessageToolCall) []string {
+			if opts.Concurrency != nil {
+				return agent.runToolCallsConcurrently(ctx, toolCalls, opts.ToolsImpl, *opts.Concurrency)
+			}
+			results := []string{}
+			for _, toolCall := range toolCalls {
+				results = append(results, agent.runToolCall(ctx, toolCall, opts.ToolsImpl))
+			}
+			return results
+		})
+		if err != nil {
+			return result, err
+		}
+
+		// IMPORTANT: the assistant message with the tool calls must precede the tool messages
+		agent.Params.Messages = append(agent.Params.Messages, message.ToParam())
+		agent.addToolMessages(message.ToolCalls, step.Results)
+		result.Steps = append(result.Steps, step)
+
+		if opts.StopCondition != nil && opts.StopCondition(step) {
+			result.Stopped = true
+			break
+		}
+		if iteration == maxIterations {
+			result.MaxIterationsReached = true
+		}
+	}
+
+	// Ask for the final answer without tools
+	answer, err := finalAnswer(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Answer = answer
+	agent.AddAssistantMessage(answer)
+	return result, nil
+}
+
+// runCompletion sends the Agent's parameters (tools included) and returns the message of the first choice.
+// Like ToolsCompletion, it calls the tools completion handlers (the final answer without tools, see run,
+// goes through the chat completion handlers): the after handlers can modify the tool calls.
+func (agent *Agent) runCompletion(ctx context.Context) (openai.ChatCompletionMessage, error) {
+	start := time.Now()
+
+	// Create context for handlers
+	handlerCtx := &ToolsCompletionContext{
+		CompletionContext: CompletionContext{
+			Agent:     agent,
+			Context:   ctx,
+			StartTime: start,
+		},
+	}
+
+	// Call before handlers
+	for _, handler := range agent.completionHandlers.BeforeToolsCompletion {
+		handler(handlerCtx)
+	}
+
+	agent.refreshChangedMCPTools(ctx)
+
+	completion, err := agent.chatProvider.ChatCompletion(ctx, agent.Params)
+	duration := time.Since(start)
+
+	var message openai.ChatCompletionMessage
+	if err == nil && len(completion.Choices) == 0 {
+		err = errors.New("no choices found")
+	}
+	if err == nil {
+		message = completion.Choices[0].Message
+	}
+
+	// Update handler context with results
+	handlerCtx.Duration = duration
+	handlerCtx.Error = err
+	handlerCtx.ToolCalls = &message.ToolCalls
+
+	// Call after handlers
+	for _, handler := range agent.completionHandlers.AfterToolsCompletion {
+		handler(handlerCtx)
+	}
+
+	if err != nil {
+		agent.logger.LogToolsCompletion(agent.Name, agent.Params, nil, duration, err)
+		return openai.ChatCompletionMessage{}, err
+	}
+	if len(message.ToolCalls) > 0 {
+		agent.logger.LogToolsCompletion(agent.Name, agent.Params, message.ToolCalls, duration, nil)
+	} else {
+		agent.logger.LogChatCompletion(agent.Name, agent.Params, message.Content, duration, nil)
+	}
+	return message, nil
+}
+
+// runToolCall executes a tool call with the local implementations or with the MCP client providing the tool.
+// It always returns the content of the tool message, errors included, so the model can react to them.
+func (agent *Agent) runToolCall(ctx context.Context, toolCall openai.ChatCompletionMessageToolCall, toolsImpl map[string]func(any) (any, error)) string {
+	return agent.resolveRunToolCall(toolCall, toolsImpl)(ctx)
+}
+
+// resolveRunToolCall returns the function executing the tool call (see resolveToolCall),
+// the function returns the content of the tool message.
+func (agent *Agent) resolveRunToolCall(toolCall openai.ChatCompletionMessageToolCall, toolsImpl map[string]func(any) (any, error)) func(ctx context.Context) string {
+	args, err := parseToolArguments(toolCall.Function.Arguments)
+	if err != nil {
+		response := fmt.Sprintf("error: invalid arguments for tool %s: %v", toolCall.Function.Name, err)
+		return func(ctx context.Context) string { return response }
+	}
+
+	call := agent.resolveToolCall(toolCall.Function.Name, args, toolsImpl)
+	return func(ctx context.Context) string {
+		// NOTE: if the tool fails, the response is the error message
+		response, _ := call(ctx)
+		return response
+	}
+}
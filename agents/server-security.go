@@ -0,0 +1,102 @@
+package agents
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// secureHandler wraps the handler of a server with the security of the agent (ServerSecurityConfig):
+// request size limit, CORS and authentication.
+func (agent *Agent) secureHandler(handler http.Handler) http.Handler {
+	security := agent.serverSecurity
+
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		// CORS
+		if origin := request.Header.Get("Origin"); origin != "" && len(security.CORSAllowedOrigins) > 0 {
+			if !security.originAllowed(origin) {
+				http.Error(response, "Error: origin not allowed", http.StatusForbidden)
+				return
+			}
+			response.Header().Set("Access-Control-Allow-Origin", origin)
+			response.Header().Add("Vary", "Origin")
+			response.Header().Set("Access-Control-Expose-Headers", "*")
+
+			// Preflight request
+			if request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != "" {
+				response.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+				// NOTE: the wildcard "*" never allows the Authorization header (Fetch specification):
+				// the headers requested by the browser are allowed (e.g. Authorization, Content-Type, the API key header)
+				if headers := request.Header.Get("Access-Control-Request-Headers"); headers != "" {
+					response.Header().Set("Access-Control-Allow-Headers", headers)
+					response.Header().Add("Vary", "Access-Control-Request-Headers")
+				}
+				response.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		// Authentication
+		if !slices.Contains(security.PublicPaths, request.URL.Path) && !security.authorized(request) {
+			response.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(response, "Error: unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Request size limit
+		if security.MaxRequestBodyBytes > 0 {
+			if request.ContentLength > security.MaxRequestBodyBytes {
+				http.Error(response, "Error: request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			request.Body = http.MaxBytesReader(response, request.Body, security.MaxRequestBodyBytes)
+		}
+
+		handler.ServeHTTP(response, request)
+	})
+}
+
+// requestBodyErrorStatus returns the HTTP status of an error reading the body of a request:
+// http.StatusRequestEntityTooLarge if the body exceeds MaxRequestBodyBytes, http.StatusBadRequest otherwise.
+func requestBodyErrorStatus(err error) int {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// authenticationRequired returns true if the servers require a bearer token or an API key.
+func (security ServerSecurityConfig) authenticationRequired() bool {
+	return len(security.BearerTokens) > 0 || len(security.APIKeys) > 0
+}
+
+func (security ServerSecurityConfig) authorized(request *http.Request) bool {
+	if !security.authenticationRequired() {
+		return true
+	}
+	if token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer "); ok && matchSecret(token, security.BearerTokens) {
+		return true
+	}
+	if key := request.Header.Get(security.APIKeyHeader); key != "" && matchSecret(key, security.APIKeys) {
+		return true
+	}
+	return false
+}
+
+func (security ServerSecurityConfig) originAllowed(origin string) bool {
+	return slices.Contains(security.CORSAllowedOrigins, "*") || slices.Contains(security.CORSAllowedOrigins, origin)
+}
+
+// matchSecret compares the value with the secrets in constant time.
+func matchSecret(value string, secrets []string) bool {
+	matched := false
+	for _, secret := range secrets {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(secret)) == 1 {
+			matched = true
+		}
+	}
+	return matched
+}
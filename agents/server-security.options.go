@@ -0,0 +1,46 @@
+package agents
+
+import "errors"
+
+// ServerSecurityConfig configures the security of the HTTP servers of the agent
+// (REST API server, MCP Streamable HTTP server and A2A server).
+// The zero value keeps the servers open, in plain HTTP and without limits.
+type ServerSecurityConfig struct {
+	// Authentication: a request is accepted if it carries one of the bearer tokens
+	// (Authorization: Bearer <token>) or one of the API keys (APIKeyHeader: <key>).
+	// No tokens and no keys: no authentication.
+	BearerTokens []string
+	APIKeys      []string
+	APIKeyHeader string // Header carrying the API key (default: "X-API-Key")
+	// PublicPaths are served without authentication (default: the A2A agent card "/.well-known/agent.json")
+	PublicPaths []string
+
+	// TLS: the servers listen in HTTPS when both files are set
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// CORS: allowed origins of the browser requests ("*": any origin, empty: no CORS headers)
+	CORSAllowedOrigins []string
+
+	// MaxRequestBodyBytes limits the size of the request bodies (0: no limit)
+	MaxRequestBodyBytes int64
+}
+
+// WithServerSecurity applies the security configuration to all the HTTP servers of the agent:
+// StartHttpServer, StartMCPHttpServer and StartA2AServer.
+// The option can be used before or after the options creating the servers.
+func WithServerSecurity(securityConfig ServerSecurityConfig) AgentOption {
+	return func(agent *Agent) {
+		if securityConfig.APIKeyHeader == "" {
+			securityConfig.APIKeyHeader = "X-API-Key"
+		}
+		if securityConfig.PublicPaths == nil {
+			securityConfig.PublicPaths = []string{"/.well-known/agent.json"}
+		}
+		if (securityConfig.TLSCertFile == "") != (securityConfig.TLSKeyFile == "") {
+			agent.optionError = errors.New("both TLSCertFile and TLSKeyFile must be set to enable TLS")
+			return
+		}
+		agent.serverSecurity = securityConfig
+	}
+}
@@ -0,0 +1,178 @@
+package agents
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// go test -v -run TestServerSecurity
+func TestServerSecurity(t *testing.T) {
+	bob, err := NewAgent("Bob",
+		WithServerSecurity(ServerSecurityConfig{
+			BearerTokens:        []string{"secret-token"},
+			APIKeys:             []string{"secret-key"},
+			CORSAllowedOrigins:  []string{"https://budgie.dev"},
+			MaxRequestBodyBytes: 16,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/chat", func(response http.ResponseWriter, request *http.Request) {
+		response.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/.well-known/agent.json", func(response http.ResponseWriter, request *http.Request) {
+		response.Write([]byte("{}"))
+	})
+	server := httptest.NewServer(bob.secureHandler(mux))
+	defer server.Close()
+
+	call := func(method, path, body string, headers map[string]string) *http.Response {
+		request, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("😡 Failed to call %s: %v", path, err)
+		}
+		response.Body.Close()
+		return response
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		headers  map[string]string
+		expected int
+	}{
+		{"no credentials", http.MethodPost, "/api/chat", "{}", nil, http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, "/api/chat", "{}", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"bearer token", http.MethodPost, "/api/chat", "{}", map[string]string{"Authorization": "Bearer secret-token"}, http.StatusOK},
+		{"api key", http.MethodPost, "/api/chat", "{}", map[string]string{"X-API-Key": "secret-key"}, http.StatusOK},
+		{"public agent card", http.MethodGet, "/.well-known/agent.json", "", nil, http.StatusOK},
+		{"body too large", http.MethodPost, "/api/chat", strings.Repeat("x", 17), map[string]string{"X-API-Key": "secret-key"}, http.StatusRequestEntityTooLarge},
+		{"origin not allowed", http.MethodPost, "/api/chat", "{}", map[string]string{"X-API-Key": "secret-key", "Origin": "https://evil.dev"}, http.StatusForbidden},
+		{"preflight", http.MethodOptions, "/api/chat", "", map[string]string{"Origin": "https://budgie.dev", "Access-Control-Request-Method": "POST"}, http.StatusNoContent},
+	}
+	for _, test := range tests {
+		response := call(test.method, test.path, test.body, test.headers)
+		if response.StatusCode != test.expected {
+			t.Errorf("😡 %s: expected %d, got %d", test.name, test.expected, response.StatusCode)
+		}
+	}
+
+	response := call(http.MethodPost, "/api/chat", "{}", map[string]string{"X-API-Key": "secret-key", "Origin": "https://budgie.dev"})
+	if response.Header.Get("Access-Control-Allow-Origin") != "https://budgie.dev" {
+		t.Errorf("😡 Expected the CORS headers, got %v", response.Header)
+	}
+
+	// The preflight of a request with a bearer token allows the Authorization header ("*" does not)
+	response = call(http.MethodOptions, "/api/chat", "", map[string]string{
+		"Origin":                         "https://budgie.dev",
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "authorization, content-type",
+	})
+	if allowed := response.Header.Get("Access-Control-Allow-Headers"); allowed != "authorization, content-type" {
+		t.Errorf("😡 Expected the requested headers to be allowed, got %q", allowed)
+	}
+
+	// TLS files must be set together
+	_, err = NewAgent("Bob", WithServerSecurity(ServerSecurityConfig{TLSCertFile: "cert.pem"}))
+	if err == nil {
+		t.Errorf("😡 Expected an error when the TLS key file is missing")
+	}
+}
+
+// go test -v -run TestServerSecurityBodyTooLarge
+func TestServerSecurityBodyTooLarge(t *testing.T) {
+	bob, err := NewAgent("Bob",
+		WithHTTPServer(HTTPServerConfig{OpenAICompatible: true}),
+		WithServerSecurity(ServerSecurityConfig{MaxRequestBodyBytes: 16}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	server := httptest.NewServer(bob.secureHandler(bob.HttpServer()))
+	defer server.Close()
+
+	for _, path := range []string{"/api/chat", "/api/chat-stream", OpenAIChatCompletionsEndpoint} {
+		// NOTE: without Content-Length (chunked body), the limit is detected while reading the body
+		body := io.MultiReader(strings.NewReader(`{"user": "` + strings.Repeat("x", 32) + `"}`))
+		response, err := http.Post(server.URL+path, "application/json", body)
+		if err != nil {
+			t.Fatalf("😡 Failed to call %s: %v", path, err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("😡 %s: expected 413, got %d", path, response.StatusCode)
+		}
+	}
+}
+
+// go test -v -run TestServerSecurityTLS
+func TestServerSecurityTLS(t *testing.T) {
+	directory := t.TempDir()
+	certFile, keyFile := filepath.Join(directory, "server.crt"), filepath.Join(directory, "server.key")
+
+	// The servers do not start without the key pair
+	bob, err := NewAgent("Bob",
+		WithHTTPServer(HTTPServerConfig{Port: "0"}),
+		WithServerSecurity(ServerSecurityConfig{TLSCertFile: certFile, TLSKeyFile: keyFile}),
+	)
+	if err != nil {
+		t.Fatalf("😡 Failed to create agent: %v", err)
+	}
+	if _, err := bob.StartHttpServerAsync(); err == nil {
+		t.Fatalf("😡 Expected an error when the TLS files are missing")
+	}
+
+	// Self-signed certificate
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv6loopback, net.IPv4(127, 0, 0, 1)},
+	}
+	certificate, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	privateKey, _ := x509.MarshalECPrivateKey(key)
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateKey}), 0o600)
+
+	agentServer, err := bob.StartHttpServerAsync()
+	if err != nil {
+		t.Fatalf("😡 Failed to start the TLS server: %v", err)
+	}
+	defer agentServer.Shutdown(context.Background())
+
+	parsed, _ := x509.ParseCertificate(certificate)
+	roots := x509.NewCertPool()
+	roots.AddCert(parsed)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	_, port, _ := net.SplitHostPort(agentServer.Addr())
+	response, err := client.Get("https://127.0.0.1:" + port + "/api/sessions/unknown")
+	if err != nil {
+		t.Fatalf("😡 Failed to call the TLS server: %v", err)
+	}
+	response.Body.Close()
+}
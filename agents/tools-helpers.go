@@ -4,11 +4,23 @@ import (
 	"github.com/openai/openai-go"
 )
 
+// AddTool adds a tool to the Agent's tools.
+// A tool with the same name is replaced, so the model never gets two definitions of a tool.
+// The implementation registered with RegisterTool for the replaced tool is removed: it decodes the arguments of the old schema.
 func (agent *Agent) AddTool(tool openai.ChatCompletionToolParam) {
+	for i, existing := range agent.Params.Tools {
+		if existing.Function.Name == tool.Function.Name {
+			agent.Params.Tools[i] = tool
+			delete(agent.toolsRegistry, tool.Function.Name)
+			return
+		}
+	}
 	agent.Params.Tools = append(agent.Params.Tools, tool)
 }
 
+// AddTools adds the tools to the Agent's tools (see AddTool).
 func (agent *Agent) AddTools(tools []openai.ChatCompletionToolParam) {
-	agent.Params.Tools = append(agent.Params.Tools, tools...)
+	for _, tool := range tools {
+		agent.AddTool(tool)
+	}
 }
-
@@ -0,0 +1,102 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/budgies-nest/budgie/helpers"
+	"github.com/openai/openai-go"
+)
+
+// RegisterTool registers a typed Go function as a tool of the Agent.
+// The JSON schema of the tool parameters is generated from the Args struct (see helpers.GenerateJSONSchema),
+// the tool is appended to the Agent's tools (replacing a tool with the same name, e.g. from WithTools), and the function is used to execute the tool calls.
+// The arguments of the tool calls are decoded into Args.
+// The result is sent to the model as is if it is a string, otherwise as JSON.
+// The registered tools are used by ExecuteToolCalls and Run when no implementation is provided for a tool.
+//
+// Example:
+//
+//	type AddArgs struct {
+//		A float64 `json:"a" description:"The first number to add."`
+//		B float64 `json:"b" description:"The second number to add."`
+//	}
+//
+//	agents.RegisterTool("add", "add two numbers", func(args AddArgs) (float64, error) {
+//		return args.A + args.B, nil
+//	})
+func RegisterTool[Args any, Result any](name string, description string, fn func(args Args) (Result, error)) AgentOption {
+	return func(agent *Agent) {
+		agent.AddTool(openai.ChatCompletionToolParam{
+			Function: openai.FunctionDefinitionParam{
+				Name:        name,
+				Description: openai.String(description),
+				Parameters:  openai.FunctionParameters(helpers.JSONSchemaOf[Args]()),
+			},
+		})
+
+		if agent.toolsRegistry == nil {
+			agent.toolsRegistry = make(map[string]func(any) (any, error))
+		}
+		agent.toolsRegistry[name] = func(args any) (any, error) {
+			var typedArgs Args
+			if err := decodeToolArguments(args, &typedArgs); err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool %s: %w", name, err)
+			}
+			result, err := fn(typedArgs)
+			if err != nil {
+				return nil, err
+			}
+			return encodeToolResult(result)
+		}
+	}
+}
+
+// RegisteredTools returns the implementations of the tools registered with RegisterTool.
+func (agent *Agent) RegisteredTools() map[string]func(any) (any, error) {
+	return agent.toolsRegistry
+}
+
+// toolImpl returns the implementation of a tool:
+// the one from toolsImpl if any, otherwise the registered one.
+func (agent *Agent) toolImpl(toolName string, toolsImpl map[string]func(any) (any, error)) (func(any) (any, error), bool) {
+	if toolFunc, ok := toolsImpl[toolName]; ok {
+		return toolFunc, true
+	}
+	toolFunc, ok := agent.toolsRegistry[toolName]
+	return toolFunc, ok
+}
+
+// decodeToolArguments decodes the arguments of a tool call (JSON or already unmarshalled) into target.
+func decodeToolArguments(args any, target any) error {
+	var data []byte
+	switch value := args.(type) {
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	case json.RawMessage:
+		data = value
+	default:
+		jsonData, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		data = jsonData
+	}
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(data, target)
+}
+
+func encodeToolResult(result any) (any, error) {
+	if str, ok := result.(string); ok {
+		return str, nil
+	}
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return string(jsonData), nil
+}
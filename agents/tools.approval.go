@@ -0,0 +1,214 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/openai/openai-go"
+)
+
+// ToolDecision is the decision of a ToolPolicy for a tool call.
+type ToolDecision string
+
+const (
+	ToolAutoRun ToolDecision = "auto"    // The tool call is executed
+	ToolConfirm ToolDecision = "confirm" // The tool call is executed once approved by the ToolApprover of the agent
+	ToolDeny    ToolDecision = "deny"    // The tool call is never executed
+)
+
+// ErrToolCallDenied is the error of the tool calls denied by the ToolPolicy or by the ToolApprover.
+var ErrToolCallDenied = errors.New("tool call denied")
+
+// ToolApprovalRequest describes a tool call submitted to the ToolPolicy and to the ToolApprover.
+type ToolApprovalRequest struct {
+	AgentName  string         `json:"agentName"`
+	ToolCallID string         `json:"toolCallId"`
+	ToolName   string         `json:"toolName"`
+	Arguments  map[string]any `json:"arguments"`
+	MCPClient  string         `json:"mcpClient,omitempty"` // Name of the MCP client providing the tool (empty for a local tool)
+}
+
+// ToolApproval is the answer of a ToolApprover.
+type ToolApproval struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"` // Optional, sent to the model when the tool call is denied
+}
+
+// ToolPolicy classifies the tool calls: auto-run, confirm or deny.
+type ToolPolicy func(request ToolApprovalRequest) ToolDecision
+
+// ToolApprover approves or denies the tool calls classified as ToolConfirm, e.g. by asking a human
+// (see TUIToolApprover, HTTPToolApprover and A2AToolApprover).
+// If the approver fails, the tool call is denied, except for a ToolApprovalRequiredError which stops the tool calling loop.
+type ToolApprover func(ctx context.Context, request ToolApprovalRequest) (ToolApproval, error)
+
+// ToolApprovalRequiredError is returned by a ToolApprover when the approval cannot be given now (e.g. A2AToolApprover):
+// the tool calls are not executed, and Run, RunStream and the Execute*ToolCalls methods return the error.
+type ToolApprovalRequiredError struct {
+	Request ToolApprovalRequest
+}
+
+func (err *ToolApprovalRequiredError) Error() string {
+	return fmt.Sprintf("approval required for the tool call %s", err.Request.ToolName)
+}
+
+// WithToolPolicy sets the policy classifying the tool calls of the agent (auto-run, confirm or deny).
+// It applies to Run, RunStream and the Execute*ToolCalls methods. Without policy, all the tool calls are executed.
+// The denied tool calls are not executed: their tool message is the error ("error: tool call denied: ...")
+// so the model can react to it.
+//
+// Example:
+//
+//	agents.WithToolPolicy(agents.ToolPolicyByName(map[string]agents.ToolDecision{
+//		"read_*":   agents.ToolAutoRun,
+//		"delete_*": agents.ToolConfirm,
+//		"drop_db":  agents.ToolDeny,
+//	}, agents.ToolConfirm)),
+//	agents.WithToolApprover(agents.TUIToolApprover()),
+func WithToolPolicy(policy ToolPolicy) AgentOption {
+	return func(agent *Agent) {
+		agent.toolPolicy = policy
+	}
+}
+
+// WithToolApprover sets the approver of the tool calls classified as ToolConfirm by the ToolPolicy.
+// Without approver, these tool calls are denied.
+func WithToolApprover(approver ToolApprover) AgentOption {
+	return func(agent *Agent) {
+		agent.toolApprover = approver
+	}
+}
+
+// ToolPolicyByName returns a policy using the names of the tools. The keys of rules are names or patterns
+// (path.Match syntax, e.g. "delete_*" or "github__*" for the namespaced MCP tools).
+// An exact name wins over the patterns; if several patterns match, the strictest decision wins (deny, confirm, auto-run).
+// The tools matching no rule get the fallback decision.
+func ToolPolicyByName(rules map[string]ToolDecision, fallback ToolDecision) ToolPolicy {
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	return func(request ToolApprovalRequest) ToolDecision {
+		if decision, ok := rules[request.ToolName]; ok {
+			return decision
+		}
+		var decision ToolDecision
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, request.ToolName); matched && toolDecisionRank(rules[pattern]) > toolDecisionRank(decision) {
+				decision = rules[pattern]
+			}
+		}
+		if decision == "" {
+			return fallback
+		}
+		return decision
+	}
+}
+
+func toolDecisionRank(decision ToolDecision) int {
+	switch decision {
+	case ToolDeny:
+		return 3
+	case ToolConfirm:
+		return 2
+	case ToolAutoRun:
+		return 1
+	}
+	return 0
+}
+
+// runApprovedToolCalls submits the tool calls to the tool policy (and to the approver) before executing them.
+// execute runs the approved tool calls and returns the contents of their tool messages;
+// the denied tool calls get the error message. The contents are returned in the order of the tool calls.
+// If the approver returns a ToolApprovalRequiredError, no tool call is executed and the error is returned.
+func (agent *Agent) runApprovedToolCalls(ctx context.Context, toolCalls []openai.ChatCompletionMessageToolCall, execute func(toolCalls []openai.ChatCompletionMessageToolCall) []string) ([]string, error) {
+	if agent.toolPolicy == nil {
+		return execute(toolCalls), nil
+	}
+
+	responses := make([]string, len(toolCalls))
+	approvedIndexes := []int{}
+	approvedToolCalls := []openai.ChatCompletionMessageToolCall{}
+	for i, toolCall := range toolCalls {
+		err := agent.approveToolCall(ctx, toolCall)
+		var approvalRequired *ToolApprovalRequiredError
+		if errors.As(err, &approvalRequired) {
+			return nil, err
+		}
+		if err != nil {
+			responses[i] = toolErrorMessage(err)
+			continue
+		}
+		approvedIndexes = append(approvedIndexes, i)
+		approvedToolCalls = append(approvedToolCalls, toolCall)
+	}
+
+	if len(approvedToolCalls) > 0 {
+		for i, response := range execute(approvedToolCalls) {
+			responses[approvedIndexes[i]] = response
+		}
+	}
+	return responses, nil
+}
+
+// approveToolCall returns nil if the tool call can be executed, an ErrToolCallDenied error otherwise,
+// or the ToolApprovalRequiredError of the approver.
+func (agent *Agent) approveToolCall(ctx context.Context, toolCall openai.ChatCompletionMessageToolCall) error {
+	request := ToolApprovalRequest{
+		AgentName:  agent.Name,
+		ToolCallID: toolCall.ID,
+		ToolName:   toolCall.Function.Name,
+	}
+	// NOTE: the invalid arguments are reported by the execution of the tool call
+	json.Unmarshal([]byte(toolCall.Function.Arguments), &request.Arguments)
+	if origin, ok := agent.mcpToolsOrigin[request.ToolName]; ok {
+		request.MCPClient = origin.clientName
+	}
+
+	var err error
+	switch decision := agent.toolPolicy(request); decision {
+	case ToolAutoRun:
+		return nil
+	case ToolConfirm:
+		err = agent.confirmToolCall(ctx, request)
+	case ToolDeny:
+		err = fmt.Errorf("%w: %s", ErrToolCallDenied, request.ToolName)
+	default:
+		err = fmt.Errorf("%w: %s (unknown policy decision %q)", ErrToolCallDenied, request.ToolName, decision)
+	}
+	if err != nil {
+		agent.logger.LogError(agent.Name, "tool_approval", "Tool call not approved", err, map[string]any{
+			"tool_name":    request.ToolName,
+			"tool_call_id": request.ToolCallID,
+		})
+	}
+	return err
+}
+
+// confirmToolCall asks the approver of the agent; without approver, or if the approver fails, the tool call is denied.
+func (agent *Agent) confirmToolCall(ctx context.Context, request ToolApprovalRequest) error {
+	if agent.toolApprover == nil {
+		return fmt.Errorf("%w: %s (no approver)", ErrToolCallDenied, request.ToolName)
+	}
+	approval, err := agent.toolApprover(ctx, request)
+	var approvalRequired *ToolApprovalRequiredError
+	if errors.As(err, &approvalRequired) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s (approval failed: %v)", ErrToolCallDenied, request.ToolName, err)
+	}
+	if !approval.Approved {
+		if approval.Reason != "" {
+			return fmt.Errorf("%w: %s (%s)", ErrToolCallDenied, request.ToolName, approval.Reason)
+		}
+		return fmt.Errorf("%w: %s", ErrToolCallDenied, request.ToolName)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/charmbracelet/huh"
+)
+
+// TUIToolApprover returns an approver asking the user to confirm the tool calls in the terminal (huh confirm prompt).
+// The prompts are serialized when the tool calls are executed concurrently.
+func TUIToolApprover() ToolApprover {
+	var mutex sync.Mutex
+	return func(ctx context.Context, request ToolApprovalRequest) (ToolApproval, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		arguments, _ := json.MarshalIndent(request.Arguments, "", "  ")
+		approved := false
+		confirm := huh.NewConfirm().
+			Title(fmt.Sprintf("🛠️ %s wants to call the tool %s", request.AgentName, request.ToolName)).
+			Description(string(arguments)).
+			Affirmative("Run").
+			Negative("Deny").
+			Value(&approved)
+		if err := huh.NewForm(huh.NewGroup(confirm)).RunWithContext(ctx); err != nil {
+			return ToolApproval{}, err
+		}
+		if !approved {
+			return ToolApproval{Reason: "denied by the user"}, nil
+		}
+		return ToolApproval{Approved: true}, nil
+	}
+}
+
+// HTTPToolApprover returns an approver sending the tool calls to an HTTP callback:
+// the ToolApprovalRequest is posted as JSON to url (with the headers, e.g. Authorization),
+// and the response must be a JSON ToolApproval, e.g. {"approved": false, "reason": "not on Friday"}.
+// If the callback fails or does not answer with a 2xx status, the tool call is denied.
+func HTTPToolApprover(url string, headers map[string]string) ToolApprover {
+	return func(ctx context.Context, request ToolApprovalRequest) (ToolApproval, error) {
+		body, err := json.Marshal(request)
+		if err != nil {
+			return ToolApproval{}, err
+		}
+		httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return ToolApproval{}, err
+		}
+		httpRequest.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			httpRequest.Header.Set(name, value)
+		}
+
+		response, err := http.DefaultClient.Do(httpRequest)
+		if err != nil {
+			return ToolApproval{}, err
+		}
+		defer response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return ToolApproval{}, fmt.Errorf("the approval callback answered %s", response.Status)
+		}
+
+		var approval ToolApproval
+		if err := json.NewDecoder(response.Body).Decode(&approval); err != nil {
+			return ToolApproval{}, fmt.Errorf("invalid approval: %w", err)
+		}
+		return approval, nil
+	}
+}
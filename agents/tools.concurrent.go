@@ -0,0 +1,119 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// DefaultConcurrentMaxWorkers is the default number of tool calls executed at the same time.
+const DefaultConcurrentMaxWorkers = 4
+
+// ConcurrentExecutionConfig configures the concurrent execution of the tool calls.
+type ConcurrentExecutionConfig struct {
+	// MaxWorkers is the maximum number of tool calls executed at the same time (default: DefaultConcurrentMaxWorkers).
+	MaxWorkers int
+	// Timeout is the maximum duration of each tool call (0: no timeout, only the context cancellation).
+	// The local tools do not take a context: a tool call that times out keeps its worker until it returns.
+	Timeout time.Duration
+}
+
+// ExecuteToolCallsConcurrently executes the tool calls detected by the Agent with a bounded pool of workers.
+// It is useful when the model returns several independent tool calls (ParallelToolCalls).
+// The tool calls are routed to the local implementations (toolsImpl, then the tools registered with RegisterTool)
+// or to the MCP clients of the agent.
+// Each tool call gets its own timeout derived from ctx; a tool call that fails or times out gets an error message.
+// Once all the tool calls are done, a tool message is appended to the Agent's messages for each of them,
+// in the same order as detectedToolCalls. The returned responses follow the same order.
+func (agent *Agent) ExecuteToolCallsConcurrently(ctx context.Context, detectedToolCalls []openai.ChatCompletionMessageToolCall, toolsImpl map[string]func(any) (any, error), config ConcurrentExecutionConfig) ([]string, error) {
+	if len(detectedToolCalls) == 0 {
+		return nil, errors.New("no tool responses found")
+	}
+
+	responses, err := agent.runApprovedToolCalls(ctx, detectedToolCalls, func(toolCalls []openai.ChatCompletionMessageToolCall) []string {
+		return agent.runToolCallsConcurrently(ctx, toolCalls, toolsImpl, config)
+	})
+	if err != nil {
+		return nil, err
+	}
+	agent.addToolMessages(detectedToolCalls, responses)
+	return responses, nil
+}
+
+// runToolCallsConcurrently executes the tool calls with a bounded pool of workers
+// and returns the contents of the tool messages in the order of the tool calls.
+// A worker is released when its tool call returns, not when it times out:
+// so there are never more than MaxWorkers tool calls running, even when the tools ignore the timeouts.
+func (agent *Agent) runToolCallsConcurrently(ctx context.Context, toolCalls []openai.ChatCompletionMessageToolCall, toolsImpl map[string]func(any) (any, error), config ConcurrentExecutionConfig) []string {
+	maxWorkers := config.MaxWorkers
+	if maxWorkers <= 0 {
+		maxWorkers = DefaultConcurrentMaxWorkers
+	}
+
+	responses := make([]string, len(toolCalls))
+	workers := make(chan struct{}, maxWorkers)
+	var wg sync.WaitGroup
+
+	for i, toolCall := range toolCalls {
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			responses[i] = agent.toolCallNotCompleted(toolCall, ctx.Err(), 0)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = agent.runToolCallWithTimeout(ctx, toolCall, toolsImpl, config.Timeout, func() { <-workers })
+		}()
+	}
+	wg.Wait()
+
+	return responses
+}
+
+// runToolCallWithTimeout executes a tool call and stops waiting for it when the timeout expires or ctx is cancelled.
+// release is called when the tool call returns.
+// NOTE: the local tool implementations do not take a context, so a timed out call keeps running in the background
+// (and keeps its worker) until it returns. The MCP tool calls are cancelled with the context.
+// The tool call is resolved before it starts: the call running in the background never reads the agent
+// (e.g. the tools of the agent changed by Run after the timeout).
+func (agent *Agent) runToolCallWithTimeout(ctx context.Context, toolCall openai.ChatCompletionMessageToolCall, toolsImpl map[string]func(any) (any, error), timeout time.Duration, release func()) string {
+	var callCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		callCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	call := agent.resolveRunToolCall(toolCall, toolsImpl)
+	done := make(chan string, 1)
+	go func() {
+		defer release()
+		done <- call(callCtx)
+	}()
+
+	select {
+	case content := <-done:
+		return content
+	case <-callCtx.Done():
+		return agent.toolCallNotCompleted(toolCall, callCtx.Err(), timeout)
+	}
+}
+
+// toolCallNotCompleted logs a tool call that timed out or was cancelled and returns the content of its tool message.
+func (agent *Agent) toolCallNotCompleted(toolCall openai.ChatCompletionMessageToolCall, err error, timeout time.Duration) string {
+	agent.logger.LogError(agent.Name, "tool_execution", fmt.Sprintf("Tool '%s' did not complete", toolCall.Function.Name), err, map[string]any{
+		"tool_name": toolCall.Function.Name,
+	})
+	if timeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("error: tool %s timed out after %s", toolCall.Function.Name, timeout)
+	}
+	return fmt.Sprintf("error: tool %s was cancelled: %v", toolCall.Function.Name, err)
+}
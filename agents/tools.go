@@ -5,156 +5,270 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/openai/openai-go"
 )
 
 // ExecuteToolCalls executes the tool calls detected by the Agent.
+// The tools without implementation in toolsImpl are executed with the tools registered with RegisterTool,
+// then with the MCP client providing the tool (see ExecuteToolCallsContext).
 // QUESTION: Should I return []any instead of []string?
 func (agent *Agent) ExecuteToolCalls(detectedtToolCalls []openai.ChatCompletionMessageToolCall, toolsImpl map[string]func(any) (any, error)) ([]string, error) {
-	responses := []string{}
-	for _, toolCall := range detectedtToolCalls {
-		// Check if the tool is implemented
-		toolFunc, ok := toolsImpl[toolCall.Function.Name]
+	return agent.ExecuteToolCallsContext(context.Background(), detectedtToolCalls, toolsImpl)
+}
 
-		if !ok { // NOTE: the tool is not implemented
-			//return nil, fmt.Errorf("tool %s not implemented", toolCall.Function.Name)
-			//fmt.Printf("✋ tool %s not implemented", toolCall.Function.Name)
-			continue
-		}
+// ErrToolNotImplemented is returned when a tool call has no local implementation and no MCP client providing it.
+var ErrToolNotImplemented = errors.New("tool not implemented")
 
-		var args map[string]any
-		err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args)
-		if err != nil {
+// ExecuteToolCallsContext executes the tool calls detected by the Agent, routing each call to where the tool comes from:
+//   - the implementation in toolsImpl, or the tool registered with RegisterTool
+//   - the MCP client providing the tool (any named client, the namespaced tools included)
+//
+// A tool message is added to the Agent's messages for each tool call.
+// If the tool fails, returns an MCP error result, or is not implemented, the message is "error: ..." so the model can recover.
+func (agent *Agent) ExecuteToolCallsContext(ctx context.Context, detectedtToolCalls []openai.ChatCompletionMessageToolCall, toolsImpl map[string]func(any) (any, error)) ([]string, error) {
+	for _, toolCall := range detectedtToolCalls {
+		if _, err := parseToolArguments(toolCall.Function.Arguments); err != nil {
 			return nil, err
 		}
+	}
 
-		// Call the tool with the arguments
-		start := time.Now()
-		toolResponse, err := toolFunc(args)
-		duration := time.Since(start)
-
-		responseStr := fmt.Sprintf("%v", toolResponse)
-		if err != nil {
-			responseStr = fmt.Sprintf("%v", err)
+	// NOTE: the tool calls are submitted to the tool policy of the agent (WithToolPolicy)
+	responses, err := agent.runApprovedToolCalls(ctx, detectedtToolCalls, func(toolCalls []openai.ChatCompletionMessageToolCall) []string {
+		responses := []string{}
+		for _, toolCall := range toolCalls {
+			args, _ := parseToolArguments(toolCall.Function.Arguments)
+			responseStr, _ := agent.executeToolCall(ctx, toolCall.Function.Name, args, toolsImpl)
 			responses = append(responses, responseStr)
-			agent.logger.LogToolExecution(agent.Name, toolCall.Function.Name, args, responseStr, duration, err)
-		} else {
-			responses = append(responses, responseStr)
-			agent.Params.Messages = append(
-				agent.Params.Messages,
-				openai.ToolMessage(
-					responseStr,
-					toolCall.ID,
-				),
-			)
-			agent.logger.LogToolExecution(agent.Name, toolCall.Function.Name, args, responseStr, duration, nil)
 		}
+		return responses
+	})
+	if err != nil {
+		return nil, err
 	}
+	agent.addToolMessages(detectedtToolCalls, responses)
 	if len(responses) == 0 {
 		return nil, errors.New("no tool responses found")
 	}
 	return responses, nil
 }
 
+// addToolMessages appends a tool message to the Agent's messages for each tool call,
+// with the response at the same index.
+func (agent *Agent) addToolMessages(toolCalls []openai.ChatCompletionMessageToolCall, responses []string) {
+	for i, toolCall := range toolCalls {
+		agent.Params.Messages = append(agent.Params.Messages, openai.ToolMessage(responses[i], toolCall.ID))
+	}
+}
 
-// TODO: check what will happend if the tool does not xist
-// ExecuteMCPStdioToolCalls executes the tool calls detected by the Agent using the MCP STDIO client.
-func (agent *Agent) ExecuteMCPStdioToolCalls(ctx context.Context, detectedtToolCalls []openai.ChatCompletionMessageToolCall) ([]string, error) {
-	responses := []string{}
-	for _, toolCall := range detectedtToolCalls {
-
-		var args map[string]any
-		err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args)
-		if err != nil {
-			return nil, err
-		}
+// parseToolArguments decodes the JSON arguments of a tool call.
+// The models may send empty arguments ("") for the tools without parameters: they are decoded as {}.
+func parseToolArguments(arguments string) (map[string]any, error) {
+	args := map[string]any{}
+	if strings.TrimSpace(arguments) == "" {
+		return args, nil
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
 
-		// NOTE: Call the MCP tool with the arguments
-		request := mcp.CallToolRequest{}
-		request.Params.Name = toolCall.Function.Name
-		request.Params.Arguments = args
+// executeToolCall calls the tool with the local implementation, or with the MCP client providing the tool.
+// It always returns the content of the tool message: if the tool fails, it is the error message ("error: ...").
+func (agent *Agent) executeToolCall(ctx context.Context, toolName string, args map[string]any, toolsImpl map[string]func(any) (any, error)) (string, error) {
+	return agent.resolveToolCall(toolName, args, toolsImpl)(ctx)
+}
 
-		// Call the tool with the arguments thanks to the MCP client
-		start := time.Now()
-		toolResponse, err := agent.mpcStdioClient.CallTool(ctx, request)
-		duration := time.Since(start)
+// resolveToolCall validates the arguments and finds the implementation of the tool (local or MCP),
+// and returns the function executing the tool call.
+// The returned function does not read the agent: it can outlive the caller
+// (e.g. a local tool ignoring the timeout of a concurrent execution keeps running in the background).
+// Like executeToolCall, the function returns the content of the tool message, errors included.
+func (agent *Agent) resolveToolCall(toolName string, args map[string]any, toolsImpl map[string]func(any) (any, error)) func(ctx context.Context) (string, error) {
+	// NOTE: the tool is not called with invalid arguments, the model can fix them (ToolArgumentsError)
+	if err := agent.validateToolArguments(toolName, args); err != nil {
+		return failedToolCall(err)
+	}
 
-		if err != nil {
-			responseStr := fmt.Sprintf("%v", err)
-			responses = append(responses, responseStr)
-			agent.logger.LogMCPToolExecution(agent.Name, toolCall.Function.Name, args, responseStr, "stdio", duration, err)
-		} else {
-			if toolResponse != nil && len(toolResponse.Content) > 0 {
-				// TODO: test if the content is a TextContent 
-				result := toolResponse.Content[0].(mcp.TextContent).Text
-
-				agent.Params.Messages = append(
-					agent.Params.Messages,
-					openai.ToolMessage(
-						result,
-						toolCall.ID,
-					),
-				)
-				responses = append(responses, result)
-				agent.logger.LogMCPToolExecution(agent.Name, toolCall.Function.Name, args, result, "stdio", duration, nil)
+	agentName, logger := agent.Name, agent.logger
+	if toolFunc, ok := agent.toolImpl(toolName, toolsImpl); ok {
+		return func(ctx context.Context) (string, error) {
+			response, err := callTool(agentName, logger, toolName, toolFunc, args)
+			if err != nil {
+				return toolErrorMessage(err), err
+			}
+			return response, nil
+		}
+	}
+	if origin, ok := agent.mcpToolsOrigin[toolName]; ok {
+		connection := agent.mcpClients[origin.clientName]
+		return func(ctx context.Context) (string, error) {
+			response, err := callMCPTool(ctx, agentName, logger, connection, origin.clientName, origin.toolName, args)
+			if err != nil {
+				return toolErrorMessage(err), err
 			}
+			return response, nil
 		}
+	}
+	err := fmt.Errorf("%w: %s", ErrToolNotImplemented, toolName)
+	agent.logger.LogError(agent.Name, "tool_execution", "Tool not found", err, map[string]any{
+		"tool_name": toolName,
+	})
+	return failedToolCall(err)
+}
 
+// failedToolCall returns a tool call failing with err (see resolveToolCall).
+func failedToolCall(err error) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		return toolErrorMessage(err), err
 	}
-	if len(responses) == 0 {
-		return nil, errors.New("no tool responses found")
+}
+
+// toolErrorMessage is the content of the tool message of a failed tool call.
+func toolErrorMessage(err error) string {
+	return fmt.Sprintf("error: %v", err)
+}
+
+// callTool calls a local tool implementation with the arguments and logs the execution.
+// If the tool fails, the returned string is the error message.
+func callTool(agentName string, logger *Logger, toolName string, toolFunc func(any) (any, error), args map[string]any) (string, error) {
+	start := time.Now()
+	toolResponse, err := toolFunc(args)
+	duration := time.Since(start)
+
+	responseStr := fmt.Sprintf("%v", toolResponse)
+	if err != nil {
+		responseStr = fmt.Sprintf("%v", err)
 	}
-	return responses, nil
+	logger.LogToolExecution(agentName, toolName, args, responseStr, duration, err)
+	return responseStr, err
+}
+
+// ExecuteMCPStdioToolCalls executes the tool calls detected by the Agent using the MCP STDIO client ("stdio").
+func (agent *Agent) ExecuteMCPStdioToolCalls(ctx context.Context, detectedtToolCalls []openai.ChatCompletionMessageToolCall) ([]string, error) {
+	return agent.ExecuteMCPToolCalls(ctx, DefaultMCPStdioClientName, detectedtToolCalls)
 }
 
-// ExecuteMCPStreamableHTTPToolCalls executes the tool calls detected by the Agent using the MCP Streamable HTTP client.
+// ExecuteMCPStreamableHTTPToolCalls executes the tool calls detected by the Agent using the MCP Streamable HTTP client ("http").
 func (agent *Agent) ExecuteMCPStreamableHTTPToolCalls(ctx context.Context, detectedtToolCalls []openai.ChatCompletionMessageToolCall) ([]string, error) {
-	responses := []string{}
-	for _, toolCall := range detectedtToolCalls {
+	return agent.ExecuteMCPToolCalls(ctx, DefaultMCPStreamableHTTPClientName, detectedtToolCalls)
+}
 
-		var args map[string]any
-		err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args)
-		if err != nil {
+// ExecuteMCPToolCalls executes the tool calls detected by the Agent using the named MCP client.
+// The namespaced tool names ({client name}__{tool name}) are translated to the names of the MCP server.
+// A tool message is added to the Agent's messages for each tool call ("error: ..." if the call fails).
+func (agent *Agent) ExecuteMCPToolCalls(ctx context.Context, clientName string, detectedtToolCalls []openai.ChatCompletionMessageToolCall) ([]string, error) {
+	connection := agent.mcpClients[clientName]
+	for _, toolCall := range detectedtToolCalls {
+		if _, err := parseToolArguments(toolCall.Function.Arguments); err != nil {
 			return nil, err
 		}
+	}
 
-		// NOTE: Call the MCP tool with the arguments
-		request := mcp.CallToolRequest{}
-		request.Params.Name = toolCall.Function.Name
-		request.Params.Arguments = args
+	// NOTE: the tool calls are submitted to the tool policy of the agent (WithToolPolicy)
+	responses, err := agent.runApprovedToolCalls(ctx, detectedtToolCalls, func(toolCalls []openai.ChatCompletionMessageToolCall) []string {
+		responses := []string{}
+		for _, toolCall := range toolCalls {
+			args, _ := parseToolArguments(toolCall.Function.Arguments)
 
-		// Call the tool with the arguments thanks to the MCP client
-		start := time.Now()
-		toolResponse, err := agent.mcpStreamableHTTPClient.CallTool(ctx, request)
-		duration := time.Since(start)
+			toolName := toolCall.Function.Name
+			if origin, ok := agent.mcpToolsOrigin[toolName]; ok && origin.clientName == clientName {
+				toolName = origin.toolName
+			}
 
-		if err != nil {
-			responseStr := fmt.Sprintf("%v", err)
-			responses = append(responses, responseStr)
-			agent.logger.LogMCPToolExecution(agent.Name, toolCall.Function.Name, args, responseStr, "http", duration, err)
-		} else {
-			if toolResponse != nil && len(toolResponse.Content) > 0 {
-				// TODO: test if the content is a TextContent 
-				result := toolResponse.Content[0].(mcp.TextContent).Text
-
-				agent.Params.Messages = append(
-					agent.Params.Messages,
-					openai.ToolMessage(
-						result,
-						toolCall.ID,
-					),
-				)
-				responses = append(responses, result)
-				agent.logger.LogMCPToolExecution(agent.Name, toolCall.Function.Name, args, result, "http", duration, nil)
+			var result string
+			err := agent.validateToolArguments(toolCall.Function.Name, args)
+			if err == nil {
+				result, err = callMCPTool(ctx, agent.Name, agent.logger, connection, clientName, toolName, args)
 			}
+			if err != nil {
+				result = toolErrorMessage(err)
+			}
+			responses = append(responses, result)
 		}
-
+		return responses
+	})
+	if err != nil {
+		return nil, err
 	}
+	agent.addToolMessages(detectedtToolCalls, responses)
 	if len(responses) == 0 {
 		return nil, errors.New("no tool responses found")
 	}
 	return responses, nil
 }
+
+// callMCPTool calls a tool with the given MCP client and returns the text of the response (see mcpContentText).
+// If the MCP server reports an error (IsError), the error contains the text of the response.
+// The call is logged with the name of the client (e.g. "stdio" or "http").
+func callMCPTool(ctx context.Context, agentName string, logger *Logger, connection *mcpConnection, clientName string, toolName string, args map[string]any) (string, error) {
+	if connection == nil {
+		return "", fmt.Errorf("no MCP %s client configured for tool %s", clientName, toolName)
+	}
+
+	// NOTE: Call the MCP tool with the arguments
+	request := mcp.CallToolRequest{}
+	request.Params.Name = toolName
+	request.Params.Arguments = args
+
+	// Call the tool with the arguments thanks to the MCP client
+	start := time.Now()
+	var toolResponse *mcp.CallToolResult
+	// NOTE: a tool call is not retried after a reconnection, the tool may have been executed
+	err := connection.doOnce(ctx, func(ctx context.Context, mcpClient *client.Client) error {
+		var err error
+		toolResponse, err = mcpClient.CallTool(ctx, request)
+		return err
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		logger.LogMCPToolExecution(agentName, toolName, args, fmt.Sprintf("%v", err), clientName, duration, err)
+		return "", err
+	}
+
+	result := ""
+	if toolResponse != nil {
+		texts := []string{}
+		for _, content := range toolResponse.Content {
+			if text := mcpContentText(content); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		result = strings.Join(texts, "\n")
+		if toolResponse.IsError {
+			err = fmt.Errorf("tool %s failed: %s", toolName, result)
+		}
+	}
+	logger.LogMCPToolExecution(agentName, toolName, args, result, clientName, duration, err)
+	return result, err
+}
+
+// mcpContentText converts an MCP content to text for the model:
+// the texts and the text resources as is, a short description for the binary contents (images, audio, blobs)
+// and the resource links.
+func mcpContentText(content mcp.Content) string {
+	switch content := content.(type) {
+	case mcp.TextContent:
+		return content.Text
+	case mcp.ImageContent:
+		return fmt.Sprintf("[image: %s, %d bytes (base64)]", content.MIMEType, len(content.Data))
+	case mcp.AudioContent:
+		return fmt.Sprintf("[audio: %s, %d bytes (base64)]", content.MIMEType, len(content.Data))
+	case mcp.ResourceLink:
+		return fmt.Sprintf("[resource: %s %s %s]", content.URI, content.Name, content.Description)
+	case mcp.EmbeddedResource:
+		switch resource := content.Resource.(type) {
+		case mcp.TextResourceContents:
+			return resource.Text
+		case mcp.BlobResourceContents:
+			return fmt.Sprintf("[resource: %s, %s, %d bytes (base64)]", resource.URI, resource.MIMEType, len(resource.Blob))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/budgies-nest/budgie/helpers"
+)
+
+// DefaultToolRepairAttempts is the default number of times the model can fix the invalid arguments of a tool.
+const DefaultToolRepairAttempts = 2
+
+// ErrInvalidToolArguments is the error of the tool calls whose arguments do not match the JSON schema of the tool.
+var ErrInvalidToolArguments = errors.New("invalid tool arguments")
+
+// ToolValidationConfig configures the validation of the tool arguments (WithToolValidation).
+type ToolValidationConfig struct {
+	// Disabled disables the validation: the tools are called with the arguments of the model
+	Disabled bool
+	// MaxRepairAttempts is the number of consecutive invalid calls of a tool for which the model is asked
+	// to fix the arguments (default: DefaultToolRepairAttempts, negative: no repair).
+	// Beyond, the model is asked to stop calling the tool.
+	// The attempts are counted by conversation (each copy of the agent, e.g. an HTTP session, has its own count)
+	// and start again with each Run (RunStream).
+	MaxRepairAttempts int
+}
+
+// ToolArgumentsError is the error of a tool call whose arguments do not match the JSON schema of the tool.
+// Its message is the content of the tool message: the validation errors and the schema, so the model can fix the arguments.
+type ToolArgumentsError struct {
+	ToolName string
+	Errors   []string       // e.g. `$.a: expected number, got string`
+	Schema   map[string]any // JSON schema of the arguments (Parameters of the tool)
+	Attempt  int            // Number of consecutive invalid calls of the tool
+	Repair   bool           // True if the model is asked to fix the arguments
+}
+
+func (err *ToolArgumentsError) Error() string {
+	message := strings.Builder{}
+	fmt.Fprintf(&message, "%v for tool %s:\n- %s\n", ErrInvalidToolArguments, err.ToolName, strings.Join(err.Errors, "\n- "))
+	if err.Repair {
+		schema, _ := json.Marshal(err.Schema)
+		fmt.Fprintf(&message, "The arguments must match the JSON schema: %s\nFix the arguments and call the tool %s again.", schema, err.ToolName)
+	} else {
+		fmt.Fprintf(&message, "The arguments are still invalid after %d attempts: do not call the tool %s again.", err.Attempt, err.ToolName)
+	}
+	return message.String()
+}
+
+func (err *ToolArgumentsError) Unwrap() error {
+	return ErrInvalidToolArguments
+}
+
+// WithToolValidation configures the validation of the tool arguments against the JSON schema of the tools
+// (Parameters of the tools of the agent, the MCP tools included).
+// The validation is enabled by default, with DefaultToolRepairAttempts repair attempts.
+func WithToolValidation(config ToolValidationConfig) AgentOption {
+	return func(agent *Agent) {
+		agent.toolValidation = config
+	}
+}
+
+// toolRepairAttempts counts the consecutive invalid calls of each tool, for a conversation:
+// each copy of the agent gets its own (see withMessages) and Run resets it.
+// NOTE: the mutex is for the concurrent tool calls (ExecuteToolCallsConcurrently).
+type toolRepairAttempts struct {
+	mutex    sync.Mutex
+	attempts map[string]int
+}
+
+func newToolRepairAttempts() *toolRepairAttempts {
+	return &toolRepairAttempts{attempts: map[string]int{}}
+}
+
+// reset forgets the invalid calls (e.g. at the start of a Run).
+func (repairs *toolRepairAttempts) reset() {
+	if repairs == nil {
+		return
+	}
+	repairs.mutex.Lock()
+	defer repairs.mutex.Unlock()
+	clear(repairs.attempts)
+}
+
+// validateToolArguments validates the arguments of a tool call against the JSON schema of the tool.
+// It returns a *ToolArgumentsError if the arguments are invalid, nil if they are valid or if the tool has no schema.
+func (agent *Agent) validateToolArguments(toolName string, args map[string]any) error {
+	if agent.toolValidation.Disabled {
+		return nil
+	}
+	schema := agent.toolSchema(toolName)
+	if len(schema) == 0 {
+		return nil
+	}
+
+	errs := helpers.ValidateJSONSchema(schema, args)
+
+	repairs := agent.toolRepairs
+	if repairs == nil {
+		repairs = newToolRepairAttempts()
+	}
+	repairs.mutex.Lock()
+	defer repairs.mutex.Unlock()
+	if len(errs) == 0 {
+		delete(repairs.attempts, toolName)
+		return nil
+	}
+	repairs.attempts[toolName]++
+
+	maxRepairAttempts := agent.toolValidation.MaxRepairAttempts
+	if maxRepairAttempts == 0 {
+		maxRepairAttempts = DefaultToolRepairAttempts
+	}
+	err := &ToolArgumentsError{
+		ToolName: toolName,
+		Errors:   errs,
+		Schema:   schema,
+		Attempt:  repairs.attempts[toolName],
+		Repair:   repairs.attempts[toolName] <= maxRepairAttempts,
+	}
+	agent.logger.LogError(agent.Name, "tool_validation", "Invalid tool arguments", ErrInvalidToolArguments, map[string]any{
+		"tool_name": toolName,
+		"errors":    errs,
+		"attempt":   err.Attempt,
+	})
+	return err
+}
+
+// toolSchema returns the JSON schema of the arguments of the tool, decoded from JSON
+// (the schemas of the MCP tools and of the typed tools use Go types, e.g. []string).
+func (agent *Agent) toolSchema(toolName string) map[string]any {
+	for _, tool := range agent.Params.Tools {
+		if tool.Function.Name != toolName {
+			continue
+		}
+		var schema map[string]any
+		if data, err := json.Marshal(tool.Function.Parameters); err == nil {
+			json.Unmarshal(data, &schema)
+		}
+		return schema
+	}
+	return nil
+}
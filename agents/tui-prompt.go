@@ -21,6 +21,7 @@ type PromptConfig struct {
 	InterruptInstructions      string // Optional interrupt instructions (default: "(Press Ctrl+C to interrupt)")
 	CompletionInterruptMessage string // Optional completion interrupt message (default: "🚫 Completion was interrupted\n")
 	GoodbyeMessage             string // Optional goodbye message (default: "👋 Goodbye!")
+	RunOptions                 RunOptions // Optional options of the tool calling loop, used in streaming mode when the agent has tools
 }
 
 // Prompt starts an interactive TUI that allows users to chat with the agent
@@ -122,13 +123,26 @@ func (agent *Agent) Prompt(config PromptConfig) error {
 			fmt.Print(thinkingPrompt)
 			fmt.Println(interruptInstructions)
 			
-			response, err := agent.ChatCompletionStream(ctx, func(self *Agent, content string, err error) error {
+			printContent := func(self *Agent, content string, err error) error {
 				if err != nil {
 					return err
 				}
 				fmt.Print(content)
 				return nil
-			})
+			}
+
+			var err error
+			if len(agent.Params.Tools) > 0 {
+				// NOTE: the streamed tool calls are executed, RunStream adds the messages to the conversation
+				_, err = agent.RunStream(ctx, "", config.RunOptions, printContent)
+			} else {
+				var response string
+				response, err = agent.ChatCompletionStream(ctx, printContent)
+				if err == nil {
+					// Add assistant response to conversation
+					agent.AddAssistantMessage(response)
+				}
+			}
 			
 			fmt.Println() // New line after completion
 
@@ -138,9 +152,6 @@ func (agent *Agent) Prompt(config PromptConfig) error {
 				} else {
 					fmt.Printf("❌ Error: %v\n", err)
 				}
-			} else {
-				// Add assistant response to conversation
-				agent.AddAssistantMessage(response)
 			}
 		} else {
 			// Handle regular completion
@@ -131,10 +131,13 @@ func main() {
 	}
 
 	chatAgent.HttpServer().HandleFunc("POST /api/info", func(response http.ResponseWriter, request *http.Request) {
-		body := agents.GetBytesBody(request)
+		body, err := agents.GetBytesBody(request)
+		if err != nil {
+			response.Write([]byte("Error: " + err.Error()))
+		}
 		// unmarshal the json data
 		var data map[string]string
-		err := json.Unmarshal(body, &data)
+		err = json.Unmarshal(body, &data)
 		if err != nil {
 			response.Write([]byte("Error: " + err.Error()))
 		}
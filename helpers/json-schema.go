@@ -0,0 +1,173 @@
+package helpers
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchemaOf generates the JSON schema of the Go type T.
+// See GenerateJSONSchema for the supported types and struct tags.
+func JSONSchemaOf[T any]() map[string]any {
+	return GenerateJSONSchema(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// GenerateJSONSchema generates the JSON schema of a Go type with reflection.
+//
+// The struct fields are described with their tags:
+//   - `json:"name"`: the property name (`json:"-"` skips the field)
+//   - `json:",omitempty"`: the property is optional, otherwise it is required
+//   - `description:"..."`: the description of the property
+//   - `enum:"a,b,c"`: the allowed values of the property, converted to the type of the field
+//     (e.g. `enum:"1,2,3"` on an int field gives numbers; on a slice field, the allowed values of the items)
+//
+// Example:
+//
+//	type AddArgs struct {
+//		A float64 `json:"a" description:"The first number to add."`
+//		B float64 `json:"b" description:"The second number to add."`
+//	}
+func GenerateJSONSchema(t reflect.Type) map[string]any {
+	return generateJSONSchema(t, map[reflect.Type]bool{})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func generateJSONSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		// NOTE: encoding/json encodes a []byte as a base64 string
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{
+			"type":  "array",
+			"items": generateJSONSchema(t.Elem(), visiting),
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": generateJSONSchema(t.Elem(), visiting),
+		}
+	case reflect.Struct:
+		// NOTE: recursive types are described as plain objects
+		if visiting[t] {
+			return map[string]any{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]any{}
+		required := []string{}
+		addStructFields(t, properties, &required, visiting)
+
+		return map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	default:
+		// interface{} and the other kinds accept any value
+		return map[string]any{}
+	}
+}
+
+func addStructFields(t reflect.Type, properties map[string]any, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+
+		// Embedded structs without a json name are inlined
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			addStructFields(fieldType, properties, required, visiting)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := generateJSONSchema(field.Type, visiting)
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			addEnum(property, field.Type, strings.Split(enum, ","))
+		}
+		properties[name] = property
+
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// addEnum adds the allowed values to the schema of a field, converted to the kind of the field.
+// The values of a slice field are the allowed values of its items.
+func addEnum(property map[string]any, t reflect.Type, values []string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if items, ok := property["items"].(map[string]any); ok {
+			addEnum(items, t.Elem(), values)
+			return
+		}
+	}
+
+	if t.Kind() == reflect.String {
+		property["enum"] = values
+		return
+	}
+	enum := make([]any, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		var converted any = value
+		var err error
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			converted, err = strconv.ParseInt(value, 10, 64)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			converted, err = strconv.ParseUint(value, 10, 64)
+		case reflect.Float32, reflect.Float64:
+			converted, err = strconv.ParseFloat(value, 64)
+		case reflect.Bool:
+			converted, err = strconv.ParseBool(value)
+		}
+		// NOTE: a value that does not match the kind of the field is kept as a string
+		if err != nil {
+			converted = value
+		}
+		enum = append(enum, converted)
+	}
+	property["enum"] = enum
+}
@@ -0,0 +1,266 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ValidateJSONSchema validates a decoded JSON value (json.Unmarshal into any) against a JSON schema
+// and returns the validation errors (nil if the value is valid).
+//
+// It supports the subset of JSON Schema generated by GenerateJSONSchema and used by the tools:
+// type (a type or a list of types), properties, required, additionalProperties, items, enum and the date-time format.
+// The errors give the path of the invalid value, e.g. `$.address.city: expected string, got number`.
+func ValidateJSONSchema(schema map[string]any, value any) []string {
+	errs := []string{}
+	validateJSONSchema(schema, value, "$", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateJSONSchema(schema map[string]any, value any, path string, errs *[]string) {
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		valueType := jsonType(value)
+		if !slices.Contains(types, valueType) && !(valueType == "integer" && slices.Contains(types, "number")) {
+			*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, joinTypes(types), valueType))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok && !enumContains(enum, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+	}
+
+	if format, _ := schema["format"].(string); format == "date-time" {
+		if text, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				*errs = append(*errs, fmt.Sprintf("%s: %q is not a date-time (RFC 3339)", path, text))
+			}
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := value[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propertyPath := path + "." + name
+			if propertySchema, ok := properties[name].(map[string]any); ok {
+				validateJSONSchema(propertySchema, value[name], propertyPath, errs)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*errs = append(*errs, fmt.Sprintf("%s: unknown property", propertyPath))
+				}
+			case map[string]any:
+				validateJSONSchema(additional, value[name], propertyPath, errs)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				validateJSONSchema(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+// jsonType returns the JSON type of a decoded JSON value.
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return reflect.TypeOf(value).String()
+	}
+}
+
+// schemaTypes returns the types of a "type" keyword: a string or a list of strings.
+func schemaTypes(value any) []string {
+	if value, ok := value.(string); ok {
+		return []string{value}
+	}
+	return schemaStrings(value)
+}
+
+// schemaStrings returns a list of strings of the schema ([]string, or []any once decoded from JSON).
+func schemaStrings(value any) []string {
+	switch value := value.(type) {
+	case []string:
+		return value
+	case []any:
+		values := []string{}
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func joinTypes(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return fmt.Sprintf("one of %v", types)
+}
+
+func enumContains(enum any, value any) bool {
+	switch enum := enum.(type) {
+	case []string:
+		text, ok := value.(string)
+		return ok && slices.Contains(enum, text)
+	case []any:
+		for _, item := range enum {
+			if reflect.DeepEqual(item, value) {
+				return true
+			}
+			// NOTE: the numbers of the schema (e.g. int64) and of the decoded value (float64) can have different types
+			if itemNumber, ok := jsonNumber(item); ok {
+				if number, ok := jsonNumber(value); ok && itemNumber == number {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// jsonNumber converts a number of a schema or of a decoded JSON value to float64.
+func jsonNumber(value any) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case json.Number:
+		number, err := value.Float64()
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// StrictJSONSchema returns a copy of the schema following the strict mode of the structured outputs:
+// the objects with properties do not accept other properties (additionalProperties: false)
+// and all their properties are required; the optional properties (not in required, e.g. omitempty) accept null instead.
+// The strict mode does not support the maps (additionalProperties with a schema): StrictJSONSchema returns an error.
+func StrictJSONSchema(schema map[string]any) (map[string]any, error) {
+	return strictJSONSchema(schema, "$")
+}
+
+func strictJSONSchema(schema map[string]any, path string) (map[string]any, error) {
+	if _, ok := schema["additionalProperties"].(map[string]any); ok {
+		return nil, fmt.Errorf("%s: the maps are not supported in strict mode", path)
+	}
+
+	strict := make(map[string]any, len(schema)+1)
+	for key, value := range schema {
+		strict[key] = value
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		strictItems, err := strictJSONSchema(items, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		strict["items"] = strictItems
+	}
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return strict, nil
+	}
+
+	required := schemaStrings(schema["required"])
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	strictProperties := make(map[string]any, len(properties))
+	for _, name := range names {
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			strictProperties[name] = properties[name]
+			continue
+		}
+		property, err := strictJSONSchema(property, path+"."+name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(required, name) {
+			property = nullableJSONSchema(property)
+		}
+		strictProperties[name] = property
+	}
+	strict["properties"] = strictProperties
+	if _, ok := schema["additionalProperties"]; !ok {
+		strict["additionalProperties"] = false
+	}
+	strict["required"] = names
+	return strict, nil
+}
+
+// nullableJSONSchema adds null to the types (and to the allowed values) of the schema.
+// A schema without type is returned as is: it already accepts null.
+func nullableJSONSchema(schema map[string]any) map[string]any {
+	types := schemaTypes(schema["type"])
+	if len(types) == 0 || slices.Contains(types, "null") {
+		return schema
+	}
+	schema["type"] = append(slices.Clone(types), "null")
+	switch enum := schema["enum"].(type) {
+	case []string:
+		values := make([]any, 0, len(enum)+1)
+		for _, value := range enum {
+			values = append(values, value)
+		}
+		schema["enum"] = append(values, nil)
+	case []any:
+		schema["enum"] = append(slices.Clone(enum), nil)
+	}
+	return schema
+}
@@ -0,0 +1,175 @@
+package helpers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaAddress struct {
+	City string `json:"city"`
+}
+
+type schemaPerson struct {
+	Name      string            `json:"name" description:"The name of the person."`
+	Nickname  string            `json:"nickname,omitempty"`
+	Level     int               `json:"level" enum:"1,2,3"`
+	Ratio     float64           `json:"ratio,omitempty" enum:"0.5,1.5"`
+	Tags      []string          `json:"tags,omitempty" enum:"red,blue"`
+	Address   *schemaAddress    `json:"address"`
+	Birthday  time.Time         `json:"birthday,omitempty"`
+	Scores    map[string]int    `json:"scores,omitempty"`
+	Friends   []schemaPerson    `json:"friends,omitempty"`
+	Ignored   string            `json:"-"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	unexposed string
+}
+
+// go test -v -run TestGenerateJSONSchema
+func TestGenerateJSONSchema(t *testing.T) {
+	schema := JSONSchemaOf[schemaPerson]()
+
+	if !reflect.DeepEqual(schema["required"], []string{"name", "level", "address"}) {
+		t.Errorf("Unexpected required properties: %v", schema["required"])
+	}
+	properties := schema["properties"].(map[string]any)
+	if _, ok := properties["Ignored"]; ok {
+		t.Errorf("Expected the json:\"-\" field to be skipped")
+	}
+	if _, ok := properties["unexposed"]; ok {
+		t.Errorf("Expected the unexported field to be skipped")
+	}
+	if name := properties["name"].(map[string]any); name["description"] != "The name of the person." {
+		t.Errorf("Unexpected name property: %v", name)
+	}
+	if level := properties["level"].(map[string]any); !reflect.DeepEqual(level["enum"], []any{int64(1), int64(2), int64(3)}) {
+		t.Errorf("Expected a numeric enum, got %#v", level["enum"])
+	}
+	if ratio := properties["ratio"].(map[string]any); !reflect.DeepEqual(ratio["enum"], []any{0.5, 1.5}) {
+		t.Errorf("Expected a numeric enum, got %#v", ratio["enum"])
+	}
+	tags := properties["tags"].(map[string]any)
+	if !reflect.DeepEqual(tags["items"], map[string]any{"type": "string", "enum": []string{"red", "blue"}}) {
+		t.Errorf("Expected the enum on the items, got %#v", tags)
+	}
+	if birthday := properties["birthday"].(map[string]any); birthday["format"] != "date-time" {
+		t.Errorf("Unexpected birthday property: %v", birthday)
+	}
+	if address := properties["address"].(map[string]any); address["type"] != "object" {
+		t.Errorf("Unexpected address property: %v", address)
+	}
+	friends := properties["friends"].(map[string]any)
+	if !reflect.DeepEqual(friends["items"], map[string]any{"type": "object"}) {
+		t.Errorf("Expected the recursive type to be a plain object, got %v", friends["items"])
+	}
+}
+
+// go test -v -run TestGenerateJSONSchemaBytes
+func TestGenerateJSONSchemaBytes(t *testing.T) {
+	type attachment struct {
+		Content  []byte  `json:"content"`
+		Checksum [4]byte `json:"checksum"`
+	}
+	schema := JSONSchemaOf[attachment]()
+	properties := schema["properties"].(map[string]any)
+
+	// encoding/json sends a []byte as a base64 string, and a byte array as an array of numbers
+	if !reflect.DeepEqual(properties["content"], map[string]any{"type": "string", "contentEncoding": "base64"}) {
+		t.Errorf("Expected a base64 string, got %v", properties["content"])
+	}
+	if checksum := properties["checksum"].(map[string]any); checksum["type"] != "array" {
+		t.Errorf("Expected an array, got %v", checksum)
+	}
+
+	var value any
+	data, _ := json.Marshal(attachment{Content: []byte("hello")})
+	json.Unmarshal(data, &value)
+	if errs := ValidateJSONSchema(schema, value); errs != nil {
+		t.Errorf("Expected the JSON of the type to match its schema: %v", errs)
+	}
+}
+
+// go test -v -run TestValidateJSONSchema
+func TestValidateJSONSchema(t *testing.T) {
+	schema := JSONSchemaOf[schemaPerson]()
+
+	var valid any
+	json.Unmarshal([]byte(`{"name":"Bob","level":2,"ratio":1.5,"tags":["red"],"address":{"city":"Lyon"},"birthday":"2025-01-02T03:04:05Z"}`), &valid)
+	if errs := ValidateJSONSchema(schema, valid); errs != nil {
+		t.Errorf("Expected a valid value, got %v", errs)
+	}
+
+	var invalid any
+	json.Unmarshal([]byte(`{"name":42,"level":4,"tags":["green"],"address":{"city":true},"birthday":"yesterday"}`), &invalid)
+	errs := ValidateJSONSchema(schema, invalid)
+	expected := []string{
+		"$.address.city: expected string, got boolean",
+		"$.birthday: \"yesterday\" is not a date-time (RFC 3339)",
+		"$.level: 4 is not one of [1 2 3]",
+		"$.name: expected string, got integer",
+		"$.tags[0]: green is not one of [red blue]",
+	}
+	if strings.Join(errs, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected errors:\n%s", strings.Join(errs, "\n"))
+	}
+
+	if errs := ValidateJSONSchema(schema, map[string]any{}); len(errs) != 3 {
+		t.Errorf("Expected 3 missing properties, got %v", errs)
+	}
+
+	strict, _ := StrictJSONSchema(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"a": map[string]any{"type": "number"}},
+	})
+	if errs := ValidateJSONSchema(strict, map[string]any{"a": 1.0, "b": 2.0}); len(errs) != 1 || errs[0] != "$.b: unknown property" {
+		t.Errorf("Expected an unknown property error, got %v", errs)
+	}
+}
+
+// go test -v -run TestStrictJSONSchema
+func TestStrictJSONSchema(t *testing.T) {
+	// The maps are not supported in strict mode
+	schema := JSONSchemaOf[schemaPerson]()
+	if _, err := StrictJSONSchema(schema); err == nil || !strings.Contains(err.Error(), "$.metadata: the maps are not supported") {
+		t.Errorf("Expected an error for the map fields, got %v", err)
+	}
+	delete(schema["properties"].(map[string]any), "metadata")
+	delete(schema["properties"].(map[string]any), "scores")
+	strict, err := StrictJSONSchema(schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// All the properties are required, the optional ones accept null
+	properties := strict["properties"].(map[string]any)
+	if required := strict["required"].([]string); len(required) != len(properties) {
+		t.Errorf("Expected all the properties to be required, got %v", required)
+	}
+	if types := properties["nickname"].(map[string]any)["type"]; !reflect.DeepEqual(types, []string{"string", "null"}) {
+		t.Errorf("Expected the optional property to be nullable, got %v", types)
+	}
+	if types := properties["name"].(map[string]any)["type"]; types != "string" {
+		t.Errorf("Expected the required property not to be nullable, got %v", types)
+	}
+	if enum := properties["ratio"].(map[string]any)["enum"].([]any); enum[len(enum)-1] != nil {
+		t.Errorf("Expected null in the allowed values of the optional property, got %v", enum)
+	}
+	address := properties["address"].(map[string]any)
+	if address["additionalProperties"] != false || !reflect.DeepEqual(address["required"], []string{"city"}) {
+		t.Errorf("Expected the nested object to be strict, got %v", address)
+	}
+
+	var value any
+	json.Unmarshal([]byte(`{
+		"name": "Bob", "nickname": null, "level": 1, "ratio": null, "tags": null,
+		"address": {"city": "Lyon"}, "birthday": null, "friends": null
+	}`), &value)
+	if errs := ValidateJSONSchema(strict, value); errs != nil {
+		t.Errorf("Unexpected errors:\n%s", strings.Join(errs, "\n"))
+	}
+	if errs := ValidateJSONSchema(strict, map[string]any{"name": "Bob", "level": 1.0, "address": map[string]any{"city": "Lyon"}}); len(errs) != 5 {
+		t.Errorf("Expected 5 missing properties, got %v", errs)
+	}
+}